@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/banyar-sithu/video/pkg/pipeline"
+	"github.com/spf13/cobra"
+)
+
+// Flags that select the languages and the Speech-to-Text, translation and Text-to-Speech backends
+var (
+	sourceLang string
+	targetLang string
+
+	transcriberName  string
+	whisperModel     string
+	whisperServerURL string
+
+	translatorName   string
+	translatorURL    string
+	translatorModel  string
+	translatorAPIKey string
+	contextSegments  int
+	glossaryFile     string
+	doNotTranslate   string
+	memoryFile       string
+	noMemory         bool
+
+	synthesizerName string
+	synthesizerPath string
+	ttsVoice        string
+	ttsRate         int
+	ttsPitch        int
+)
+
+// addLanguageFlags registers the source and target language flags on a command
+func addLanguageFlags(cmd *cobra.Command) {
+	codes := strings.Join(pipeline.LanguageCodes(), ", ")
+	cmd.Flags().StringVar(&sourceLang, "source-lang", "en", "spoken language: "+codes+", or auto to let Whisper detect it")
+	cmd.Flags().StringVar(&targetLang, "target-lang", "my", "language to translate to: "+codes)
+}
+
+// languages looks up the languages selected by flags
+func languages() (source, target pipeline.Language, err error) {
+	if source, err = pipeline.LookupLanguage(sourceLang, true); err != nil {
+		return source, target, err
+	}
+	target, err = pipeline.LookupLanguage(targetLang, false)
+	return source, target, err
+}
+
+// addTranscriberFlags registers the speech-to-text backend flags on a command
+func addTranscriberFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&transcriberName, "transcriber", "whisper", "speech-to-text backend: whisper (local CLI) or whisper-server (HTTP)")
+	cmd.Flags().StringVar(&whisperModel, "whisper-model", "", "Whisper model name (CLI default when empty)")
+	cmd.Flags().StringVar(&whisperServerURL, "whisper-server-url", "http://127.0.0.1:8080/inference", "transcription endpoint of a whisper.cpp or OpenAI-compatible server")
+}
+
+// newTranscriber builds the Transcriber selected by flags
+func newTranscriber() (pipeline.Transcriber, error) {
+	source, _, err := languages()
+	if err != nil {
+		return nil, err
+	}
+	// Whisper rejects languages it has no model for; YouTube captions can still provide them
+	language := source.Whisper
+	if language == "" && source.Code != pipeline.AutoLanguage.Code {
+		language = source.Code
+	}
+
+	switch transcriberName {
+	case "whisper", "":
+		return &pipeline.WhisperCLI{Path: pipeline.VenvBin("whisper"), Model: whisperModel, Language: language}, nil
+	case "whisper-server":
+		return &pipeline.WhisperServer{URL: whisperServerURL, Model: whisperModel, Language: language}, nil
+	default:
+		return nil, fmt.Errorf("unknown transcriber %q", transcriberName)
+	}
+}
+
+// addTranslatorFlags registers the translation backend flags on a command
+func addTranslatorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&translatorName, "translator", "google", "translation backend: google (deep-translator), openai (chat completions), openai-context (chat completions with neighbouring segments and the video title) or libretranslate")
+	cmd.Flags().StringVar(&translatorURL, "translator-url", "", "base URL of the openai or libretranslate server (e.g. http://127.0.0.1:11434/v1)")
+	cmd.Flags().StringVar(&translatorModel, "translator-model", "", "model name for the openai backends")
+	cmd.Flags().IntVar(&contextSegments, "context-segments", 3, "segments before and after each chunk shown to the openai-context backend")
+	cmd.Flags().StringVar(&translatorAPIKey, "translator-api-key", "", "API key for the translation server (default $TRANSLATOR_API_KEY)")
+	cmd.Flags().StringVar(&glossaryFile, "glossary", "", "CSV file of \"source term,target term\" rows the translation must follow")
+	cmd.Flags().StringVar(&doNotTranslate, "do-not-translate", "", "text file with one term per line that must stay untranslated")
+	cmd.Flags().StringVar(&memoryFile, "tm", pipeline.DefaultMemoryFile, "translation memory file shared by burmese and live")
+	cmd.Flags().BoolVar(&noMemory, "no-tm", false, "always ask the translator, without the translation memory")
+}
+
+// openMemory opens the translation memory selected by flags; nil with --no-tm
+func openMemory() (*pipeline.TranslationMemory, error) {
+	if noMemory {
+		return nil, nil
+	}
+	return pipeline.OpenTranslationMemory(memoryFile)
+}
+
+// loadGlossary reads the glossary and do-not-translate files; nil when neither is set
+func loadGlossary() (*pipeline.Glossary, error) {
+	if glossaryFile == "" && doNotTranslate == "" {
+		return nil, nil
+	}
+	glossary := &pipeline.Glossary{}
+	if glossaryFile != "" {
+		var err error
+		if glossary, err = pipeline.LoadGlossary(glossaryFile); err != nil {
+			return nil, err
+		}
+	}
+	if doNotTranslate != "" {
+		if err := glossary.LoadDoNotTranslate(doNotTranslate); err != nil {
+			return nil, err
+		}
+	}
+	return glossary, nil
+}
+
+// newTranslator builds the Translator selected by flags
+func newTranslator() (pipeline.Translator, error) {
+	source, target, err := languages()
+	if err != nil {
+		return nil, err
+	}
+	apiKey := translatorAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("TRANSLATOR_API_KEY")
+	}
+
+	switch translatorName {
+	case "google", "":
+		if err := backendSupports("google", source.Google, source, target.Google, target); err != nil {
+			return nil, err
+		}
+		return &pipeline.DeepTranslator{PythonPath: pipeline.VenvBin("python3"), Source: source.Google, Target: target.Google}, nil
+	case "openai":
+		if translatorURL == "" || translatorModel == "" {
+			return nil, fmt.Errorf("openai translator needs --translator-url and --translator-model")
+		}
+		sourceName := source.Name
+		if source.Code == pipeline.AutoLanguage.Code {
+			sourceName = ""
+		}
+		return &pipeline.OpenAITranslator{BaseURL: translatorURL, Model: translatorModel, APIKey: apiKey, Source: sourceName, Target: target.Name}, nil
+	case "openai-context":
+		if translatorURL == "" || translatorModel == "" {
+			return nil, fmt.Errorf("openai-context translator needs --translator-url and --translator-model")
+		}
+		sourceName := source.Name
+		if source.Code == pipeline.AutoLanguage.Code {
+			sourceName = ""
+		}
+		return &pipeline.ContextTranslator{BaseURL: translatorURL, Model: translatorModel, APIKey: apiKey, Source: sourceName, Target: target.Name, Window: contextSegments}, nil
+	case "libretranslate":
+		if translatorURL == "" {
+			return nil, fmt.Errorf("libretranslate translator needs --translator-url")
+		}
+		if err := backendSupports("libretranslate", source.Libre, source, target.Libre, target); err != nil {
+			return nil, err
+		}
+		return &pipeline.LibreTranslator{BaseURL: translatorURL, APIKey: apiKey, Source: source.Libre, Target: target.Libre}, nil
+	default:
+		return nil, fmt.Errorf("unknown translator %q", translatorName)
+	}
+}
+
+// backendSupports checks that a translator has codes for both languages
+func backendSupports(backend, sourceCode string, source pipeline.Language, targetCode string, target pipeline.Language) error {
+	for _, l := range []struct {
+		code string
+		lang pipeline.Language
+	}{{sourceCode, source}, {targetCode, target}} {
+		if l.code == "" {
+			return fmt.Errorf("%s cannot translate %s; use --translator openai or openai-context", backend, l.lang.Name)
+		}
+	}
+	return nil
+}
+
+// addSynthesizerFlags registers the Text-to-Speech engine flags on a command
+func addSynthesizerFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&synthesizerName, "tts", "edge", "text-to-speech engine: edge (edge-tts, online), piper, espeak (offline) or fake (beeps, for tests)")
+	cmd.Flags().StringVar(&synthesizerPath, "tts-path", "", "path to the piper or espeak-ng executable (default from PATH)")
+	cmd.Flags().StringVar(&ttsVoice, "tts-voice", "", "voice name, or piper .onnx model (default: the target language's voice, picked by VOICE_PRESENTER for edge)")
+	cmd.Flags().IntVar(&ttsRate, "tts-rate", -10, "speech rate change in percent, above -100")
+	cmd.Flags().IntVar(&ttsPitch, "tts-pitch", 0, "pitch change in Hz")
+}
+
+// newSynthesizer builds the Synthesizer and Voice selected by flags
+func newSynthesizer() (pipeline.Synthesizer, pipeline.Voice, error) {
+	voice := pipeline.Voice{Name: ttsVoice, RatePercent: ttsRate, PitchHz: ttsPitch}
+	if err := pipeline.CheckRate(ttsRate); err != nil {
+		return nil, voice, fmt.Errorf("--tts-rate: %w", err)
+	}
+	_, target, err := languages()
+	if err != nil {
+		return nil, voice, err
+	}
+
+	switch synthesizerName {
+	case "edge", "":
+		if voice.Name == "" {
+			voice.Name = getVoiceName(target)
+		}
+		if voice.Name == "" {
+			return nil, voice, fmt.Errorf("edge-tts has no %s voice; pass --tts-voice or use --tts piper", target.Name)
+		}
+		return &pipeline.EdgeSynthesizer{Path: pipeline.VenvBin("edge-tts")}, voice, nil
+	case "espeak":
+		if voice.Name == "" {
+			voice.Name = target.Code
+		}
+		return &pipeline.EspeakSynthesizer{Path: orDefault(synthesizerPath, "espeak-ng")}, voice, nil
+	case "piper":
+		if voice.Name == "" {
+			return nil, voice, fmt.Errorf("piper needs --tts-voice with a .onnx voice model")
+		}
+		return &pipeline.PiperSynthesizer{Path: orDefault(synthesizerPath, "piper")}, voice, nil
+	case "fake":
+		return &pipeline.FakeSynthesizer{}, voice, nil
+	default:
+		return nil, voice, fmt.Errorf("unknown tts engine %q", synthesizerName)
+	}
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// getVoiceName returns the language's default Edge TTS voice based on VOICE_PRESENTER env value
+// Options: men/thiha -> male voice, women/girl -> female voice
+// Default: men (male voice)
+func getVoiceName(lang pipeline.Language) string {
+	presenter := strings.ToLower(os.Getenv("VOICE_PRESENTER"))
+	switch presenter {
+	case "women", "girl":
+		return lang.Voice(true) // အမျိုးသမီးအသံ
+	default:
+		return lang.Voice(false) // default: အမျိုးသားအသံ
+	}
+}
@@ -1,8 +1,8 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"syscall"
 
+	"github.com/banyar-sithu/video/pkg/pipeline"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
@@ -25,6 +26,10 @@ var liveToBurmeseCmd = &cobra.Command{
 }
 
 func init() {
+	addLanguageFlags(liveToBurmeseCmd)
+	addTranscriberFlags(liveToBurmeseCmd)
+	addTranslatorFlags(liveToBurmeseCmd)
+	addSynthesizerFlags(liveToBurmeseCmd)
 	rootCmd.AddCommand(liveToBurmeseCmd)
 }
 
@@ -34,12 +39,63 @@ const (
 	chunkDuration  = 5 // seconds per chunk for real-time processing
 )
 
+// Backends shared by all chunk workers
+var (
+	liveTranscriber pipeline.Transcriber
+	liveTranslator  pipeline.Translator
+	liveSynthesizer pipeline.Synthesizer
+	liveVoice       pipeline.Voice
+	liveTarget      pipeline.Language
+)
+
 func live() {
 	// Load .env file for voice configuration
 	if err := godotenv.Load(); err != nil {
 		fmt.Println("⚠️ Warning: .env file not found, using default voice")
 	}
 
+	transcriber, err := newTranscriber()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	liveTranscriber = transcriber
+
+	translator, err := newTranslator()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	liveTranslator = translator
+	_, liveTarget, _ = languages()
+
+	// Common phrases come back every few seconds: answer them from the translation memory
+	memory, err := openMemory()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	if memory != nil {
+		defer memory.Close()
+		source, _, _ := languages()
+		liveTranslator = &pipeline.MemoryTranslator{Translator: liveTranslator, Memory: memory, SourceLang: source.Code, TargetLang: liveTarget.Code}
+	}
+
+	glossary, err := loadGlossary()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	if glossary != nil {
+		liveTranslator = &pipeline.GlossaryTranslator{Translator: liveTranslator, Glossary: glossary}
+	}
+
+	liveSynthesizer, liveVoice, err = newSynthesizer()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
 	// Create output directory for live recordings
 	projectDir, err := os.Getwd()
 	if err != nil {
@@ -53,7 +109,7 @@ func live() {
 
 	fmt.Println("🎤 တိုက်ရိုက် ဘာသာပြန်စနစ် စတင်နေသည်...")
 	fmt.Println("📢 English စကားပြောပါ - မြန်မာလို ပြန်ပေးပါမည်")
-	fmt.Printf("🔊 Voice: %s\n", getLiveVoiceName())
+	fmt.Printf("🔊 Voice: %s (%s)\n", liveVoice.Name, synthesizerName)
 	fmt.Printf("📁 Output: %s\n", liveRecordDir)
 	fmt.Println("⏹️  ရပ်ရန် Ctrl+C နှိပ်ပါ")
 	fmt.Println(strings.Repeat("─", 50))
@@ -182,140 +238,48 @@ func processLiveTranslation(audioFile string) {
 	fmt.Println("✅ ပြီးစီးပါပြီ")
 }
 
-// getLiveProjectDir returns the current working directory
-func getLiveProjectDir() string {
-	dir, err := os.Getwd()
-	if err != nil {
-		return "."
-	}
-	return dir
-}
-
-// Speech-to-Text using Whisper
+// Speech-to-Text using the selected transcriber
+// A whisper-server transcriber avoids reloading the model for every chunk
 func liveConvertSpeechToEnglish(audioFile string) (string, error) {
-	whisperPath := filepath.Join(getLiveProjectDir(), ".venv", "bin", "whisper")
-	outputDir := filepath.Dir(audioFile)
-
-	cmd := exec.Command(whisperPath, audioFile,
-		"--language", "en",
-		"--output_format", "txt",
-		"--output_dir", outputDir)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("whisper failed: %w", err)
-	}
-
-	// Read output text file
-	baseName := strings.TrimSuffix(filepath.Base(audioFile), ".wav")
-	txtFile := filepath.Join(outputDir, baseName+".txt")
-	text, err := os.ReadFile(txtFile)
+	segments, err := liveTranscriber.Transcribe(context.Background(), audioFile)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("speech-to-text failed: %w", err)
 	}
 
-	os.Remove(txtFile) // Cleanup
-
-	return strings.TrimSpace(string(text)), nil
+	texts := make([]string, len(segments))
+	for i, s := range segments {
+		texts[i] = s.Text
+	}
+	return strings.TrimSpace(strings.Join(texts, " ")), nil
 }
 
-// Translate to Burmese using deep_translator
+// Translate to Burmese using the selected translator
 func liveTranslateToBurmese(englishText string) (string, error) {
-	pythonPath := filepath.Join(filepath.Dir(os.Args[0]), "..", ".venv", "bin", "python3")
-	if _, err := os.Stat(pythonPath); os.IsNotExist(err) {
-		pythonPath = filepath.Join(getLiveProjectDir(), ".venv", "bin", "python3")
-	}
-
-	cmd := exec.Command(pythonPath, "-c", `
-import sys
-from deep_translator import GoogleTranslator
-translator = GoogleTranslator(source='en', target='my')
-text = sys.stdin.read()
-result = translator.translate(text)
-print(result)
-`)
-
-	cmd.Stderr = os.Stderr
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return "", fmt.Errorf("stdin pipe error: %w", err)
-	}
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("stdout pipe error: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("start error: %w", err)
-	}
-
-	_, err = stdin.Write([]byte(englishText))
-	if err != nil {
-		return "", fmt.Errorf("write error: %w", err)
-	}
-	stdin.Close()
-
-	output, err := io.ReadAll(stdout)
+	results, err := liveTranslator.Translate(context.Background(), []string{englishText})
 	if err != nil {
-		return "", fmt.Errorf("read error: %w", err)
-	}
-
-	if err := cmd.Wait(); err != nil {
 		return "", fmt.Errorf("translation failed: %w", err)
 	}
 
-	return strings.TrimSpace(string(output)), nil
+	return strings.TrimSpace(liveTarget.NormalizeText(results[0])), nil
 }
 
-// getLiveVoiceName returns the Edge TTS voice based on VOICE_PRESENTER env value
-// Options: men/thiha -> male voice, women/girl -> female voice
-// Default: men (male voice)
-func getLiveVoiceName() string {
-	presenter := strings.ToLower(os.Getenv("VOICE_PRESENTER"))
-	switch presenter {
-	case "women", "girl":
-		return "my-MM-NilarNeural" // အမျိုးသမီးအသံ
-	case "men", "thiha", "":
-		return "my-MM-ThihaNeural" // အမျိုးသားအသံ
-	default:
-		return "my-MM-ThihaNeural" // default: အမျိုးသားအသံ
-	}
-}
-
-// Text-to-Speech using Edge TTS
+// Text-to-Speech using the selected synthesizer, then play it
 func liveSpeakBurmese(burmeseText string) error {
-	edgeTTSPath := filepath.Join(filepath.Dir(os.Args[0]), "..", ".venv", "bin", "edge-tts")
-	if _, err := os.Stat(edgeTTSPath); os.IsNotExist(err) {
-		edgeTTSPath = filepath.Join(getLiveProjectDir(), ".venv", "bin", "edge-tts")
+	outputAudio, err := os.CreateTemp("", "live_output_*.audio")
+	if err != nil {
+		return err
 	}
+	outputAudio.Close()
+	defer os.Remove(outputAudio.Name())
 
-	outputAudio := "live_output.mp3"
-	voiceName := getLiveVoiceName()
-
-	// Generate audio using Edge TTS
-	cmd := exec.Command(edgeTTSPath,
-		"--voice", voiceName,
-		"--text", burmeseText,
-		"--write-media", outputAudio,
-		"--rate=-10%",
-	)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("edge-tts error: %w", err)
+	// Generate audio
+	if err := liveSynthesizer.Synthesize(context.Background(), burmeseText, liveVoice, outputAudio.Name()); err != nil {
+		return err
 	}
 
 	// Play the audio
-	playCmd := exec.Command("ffplay", "-nodisp", "-autoexit", outputAudio)
+	playCmd := exec.Command("ffplay", "-nodisp", "-autoexit", "-loglevel", "error", outputAudio.Name())
 	playCmd.Run()
 
-	os.Remove(outputAudio)
-
 	return nil
 }
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/banyar-sithu/video/pkg/subtitle"
+	"github.com/spf13/cobra"
+)
+
+var (
+	subsFrom   string
+	subsTo     string
+	subsLayout = subtitle.DefaultLayout
+	subsUnit   string
+)
+
+var subsCmd = &cobra.Command{
+	Use:   "subs",
+	Short: "Work with subtitle files (SRT, WebVTT, ASS/SSA)",
+}
+
+var subsConvertCmd = &cobra.Command{
+	Use:   "convert input output",
+	Short: "Convert a subtitle file to another format",
+	Long: `Convert between SRT, WebVTT and ASS/SSA. Formats come from the file extensions
+unless --from or --to is given. Byte order marks, CRLF line ends and broken timestamps
+are handled; cues that cannot be read are skipped with a warning.`,
+	Example: `  video subs convert talk_burmese.srt talk_burmese.vtt
+  video subs convert talk.ass talk.srt
+  video subs convert captions.txt captions.ass --from srt`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		input, output := args[0], args[1]
+
+		from, to := subtitle.Format(""), subtitle.Format("")
+		var err error
+		if subsFrom != "" {
+			if from, err = subtitle.ParseFormat(subsFrom); err != nil {
+				fmt.Println("❌", err)
+				return
+			}
+		} else {
+			from, _ = subtitle.FormatOf(input) // unknown extension: detect from the content
+		}
+		if subsTo != "" {
+			to, err = subtitle.ParseFormat(subsTo)
+		} else {
+			to, err = subtitle.FormatOf(output)
+		}
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+
+		data, err := os.ReadFile(input)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		track, err := subtitle.Parse(data, from)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		for _, w := range track.Warnings {
+			fmt.Println("⚠️", w)
+		}
+
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		defer f.Close()
+		if err := track.Write(f, to); err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		fmt.Printf("✅ %d cues written to %s\n", len(track.Cues), output)
+	},
+}
+
+var subsLayoutCmd = &cobra.Command{
+	Use:   "layout input output",
+	Short: "Wrap subtitles to a line width and reading speed",
+	Long: `Re-wrap subtitles to at most --lines lines of --line-width, breaking Burmese between
+syllables, and keep each cue on screen long enough to read at --max-cps characters per
+second: cues that do not fit are split, and cues that are too fast are extended into the
+silence after them or merged with a neighbour. The burmese command does this itself
+(the layout stage); this is for subtitles edited or made elsewhere.`,
+	Example: `  video subs layout talk_burmese.srt talk_burmese.layout.srt
+  video subs layout talk.srt talk.vtt --line-width 32 --max-cps 15
+  video subs layout talk.ass talk.layout.ass --width-unit px --line-width 340 --font-size 24`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		input, output := args[0], args[1]
+
+		var err error
+		if subsLayout.Unit, err = subtitle.ParseWidthUnit(subsUnit); err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		to, err := subtitle.FormatOf(output)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		track, err := subtitle.ReadFile(input)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		for _, w := range track.Warnings {
+			fmt.Println("⚠️", w)
+		}
+
+		cues, report := subsLayout.Apply(track.Cues)
+		track.Cues = cues
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		defer f.Close()
+		if err := track.Write(f, to); err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		fmt.Printf("✅ %d cues written to %s (%d split, %d merged, %d extended)\n", len(cues), output, report.Split, report.Merged, report.Extended)
+		if report.TooFast > 0 {
+			fmt.Printf("⚠️ %d cues are still too fast to read\n", report.TooFast)
+		}
+	},
+}
+
+func init() {
+	subsConvertCmd.Flags().StringVar(&subsFrom, "from", "", "input format: srt, vtt, ass or ssa (default from the extension)")
+	subsConvertCmd.Flags().StringVar(&subsTo, "to", "", "output format: srt, vtt or ass (default from the extension)")
+	subsLayoutCmd.Flags().IntVar(&subsLayout.MaxLineWidth, "line-width", subsLayout.MaxLineWidth, "longest line, in --width-unit")
+	subsLayoutCmd.Flags().StringVar(&subsUnit, "width-unit", string(subsLayout.Unit), "unit of --line-width: chars, or px (estimated at --font-size)")
+	subsLayoutCmd.Flags().Float64Var(&subsLayout.FontSize, "font-size", subsLayout.FontSize, "font size for px widths, in the renderer's units (libass: of a 288 pixel high frame for SRT)")
+	subsLayoutCmd.Flags().IntVar(&subsLayout.MaxLines, "lines", subsLayout.MaxLines, "most lines per cue; longer cues are split")
+	subsLayoutCmd.Flags().Float64Var(&subsLayout.MaxCPS, "max-cps", subsLayout.MaxCPS, "reading speed limit in characters per second (0 = no limit)")
+	subsCmd.AddCommand(subsConvertCmd, subsLayoutCmd)
+	rootCmd.AddCommand(subsCmd)
+}
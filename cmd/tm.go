@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banyar-sithu/video/pkg/pipeline"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tmBackend       string
+	tmImportBackend string
+	tmUnused        string
+	tmMaxHits       int
+	tmLangPair      string
+)
+
+var tmCmd = &cobra.Command{
+	Use:   "tm",
+	Short: "Manage the translation memory",
+	Long: `The translation memory remembers every translated segment, keyed by translator
+backend, language pair and normalized source text, so burmese and live runs do not send
+the same sentence to the translator twice.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		memory, err := pipeline.OpenTranslationMemory(memoryFile)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		fmt.Printf("🧠 %s: %d entries\n", memory.Path(), memory.Len())
+	},
+}
+
+var tmExportCmd = &cobra.Command{
+	Use:   "export file.tmx",
+	Short: "Export the translation memory as TMX",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		memory, err := pipeline.OpenTranslationMemory(memoryFile)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		f, err := os.Create(args[0])
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		defer f.Close()
+
+		entries := memory.Entries()
+		if err := pipeline.ExportTMX(f, entries); err != nil {
+			fmt.Println("❌ TMX export error:", err)
+			return
+		}
+		fmt.Printf("✅ %d entries exported to %s\n", len(entries), args[0])
+	},
+}
+
+var tmImportCmd = &cobra.Command{
+	Use:   "import file.tmx",
+	Short: "Import translations from a TMX file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		memory, err := pipeline.OpenTranslationMemory(memoryFile)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		defer f.Close()
+
+		entries, err := pipeline.ImportTMX(f, tmImportBackend)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		if err := memory.Store(entries...); err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		fmt.Printf("✅ %d entries imported into %s\n", len(entries), memory.Path())
+	},
+}
+
+var tmPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old or unwanted entries and compact the file",
+	Long: `Remove entries matching every given filter, then rewrite the memory file.
+Without filters only duplicate lines are dropped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var unusedFor time.Duration
+		if tmUnused != "" {
+			var err error
+			if unusedFor, err = parseAge(tmUnused); err != nil {
+				fmt.Println("❌", err)
+				return
+			}
+		}
+		var sourceLang, targetLang string
+		if tmLangPair != "" {
+			var ok bool
+			if sourceLang, targetLang, ok = strings.Cut(tmLangPair, "-"); !ok {
+				fmt.Println("❌ --lang wants source-target, e.g. en-my")
+				return
+			}
+		}
+		filtered := tmBackend != "" || unusedFor > 0 || tmLangPair != "" || cmd.Flags().Changed("max-hits")
+
+		memory, err := pipeline.OpenTranslationMemory(memoryFile)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		cutoff := time.Now().Add(-unusedFor)
+		removed, err := memory.Prune(func(e pipeline.MemoryEntry) bool {
+			return filtered &&
+				(tmBackend == "" || e.Backend == tmBackend) &&
+				(unusedFor == 0 || e.LastUsed.Before(cutoff)) &&
+				(tmLangPair == "" || e.SourceLang == sourceLang && e.TargetLang == targetLang) &&
+				(!cmd.Flags().Changed("max-hits") || e.Hits <= tmMaxHits)
+		})
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		fmt.Printf("✅ %d entries removed, %d left\n", removed, memory.Len())
+	},
+}
+
+func init() {
+	tmCmd.PersistentFlags().StringVar(&memoryFile, "tm", pipeline.DefaultMemoryFile, "translation memory file")
+	tmImportCmd.Flags().StringVar(&tmImportBackend, "backend", "google", "backend for units without an x-backend property (google, openai/<model>, libretranslate)")
+	tmPruneCmd.Flags().StringVar(&tmBackend, "backend", "", "only entries of this backend")
+	tmPruneCmd.Flags().StringVar(&tmUnused, "unused-for", "", "only entries not used for this long, e.g. 90d or 720h")
+	tmPruneCmd.Flags().StringVar(&tmLangPair, "lang", "", "only this language pair, e.g. en-my")
+	tmPruneCmd.Flags().IntVar(&tmMaxHits, "max-hits", 0, "only entries used at most this many times")
+	tmCmd.AddCommand(tmExportCmd, tmImportCmd, tmPruneCmd)
+	rootCmd.AddCommand(tmCmd)
+}
+
+// parseAge accepts Go durations plus a days suffix ("90d")
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -1,459 +1,294 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/banyar-sithu/video/pkg/pipeline"
+	"github.com/banyar-sithu/video/pkg/subtitle"
 	"github.com/joho/godotenv"
-	"github.com/kkdai/youtube/v2"
 	"github.com/spf13/cobra"
 )
 
-var name string
+var (
+	name             string
+	subtitleStyle    = pipeline.DefaultSubtitleStyle
+	subtitleLayout   = pipeline.DefaultSubtitleLayout
+	layoutUnit       string
+	bilingual        bool
+	download         = pipeline.DefaultDownloadOptions
+	translation      = pipeline.DefaultTranslateOptions
+	onTranslateError string
+	fromStage        string
+	onlyStage        string
+	sourceList       string
+	jobs             int
+	nameTemplate     string
+	captions         string
+	targetSubs       string
+)
 
 var toBurmeseCmd = &cobra.Command{
-	Use:   "burmese",
-	Short: "Video download from youtube and to change burmese language video",
-	Long:  "Print a message. Use --name to specify who to .",
+	Use:     "burmese [youtube-url | video-id | playlist-url | file]...",
+	Aliases: []string{"translate"},
+	Short:   "Video download from youtube and to change burmese language video",
+	Long: `Translate videos to Burmese (or another --target-lang) with subtitles and dubbed audio.
+
+Each argument is a YouTube URL, a YouTube video ID, a playlist or channel URL, or a
+local video or audio file. Local files skip the download. Playlists and channels are
+expanded to their videos. Without arguments DOWNLOAD_YOUTUBE_URL from .env is used.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		video()
+		video(cmd, args)
 	},
 }
 
 func init() {
 	toBurmeseCmd.Flags().StringVarP(&name, "name", "n", "World", "name of the person to greet")
+	toBurmeseCmd.Flags().StringVar(&subtitleStyle.FontFile, "subtitle-font", "", "font file (.ttf/.otf) with Myanmar glyphs used to burn subtitles")
+	toBurmeseCmd.Flags().StringVar(&subtitleStyle.FontName, "subtitle-font-name", subtitleStyle.FontName, "font family name inside the subtitle font file (default follows --target-lang)")
+	toBurmeseCmd.Flags().IntVar(&subtitleStyle.FontSize, "subtitle-size", subtitleStyle.FontSize, "burned subtitle font size")
+	toBurmeseCmd.Flags().IntVar(&subtitleStyle.Outline, "subtitle-outline", subtitleStyle.Outline, "burned subtitle outline thickness")
+	toBurmeseCmd.Flags().IntVar(&subtitleStyle.MarginV, "subtitle-margin", subtitleStyle.MarginV, "burned subtitle bottom margin")
+	toBurmeseCmd.Flags().IntVar(&subtitleLayout.MaxLineWidth, "subtitle-line-width", subtitleLayout.MaxLineWidth, "longest subtitle line, in --subtitle-width-unit")
+	toBurmeseCmd.Flags().StringVar(&layoutUnit, "subtitle-width-unit", string(subtitleLayout.Unit), "unit of --subtitle-line-width: px (estimated at --subtitle-size, on libass's 384x288 frame) or chars")
+	toBurmeseCmd.Flags().IntVar(&subtitleLayout.MaxLines, "subtitle-lines", subtitleLayout.MaxLines, "most lines per subtitle; longer subtitles are split")
+	toBurmeseCmd.Flags().Float64Var(&subtitleLayout.MaxCPS, "subtitle-max-cps", subtitleLayout.MaxCPS, "reading speed limit in characters per second; faster subtitles are extended or merged (0 = no limit)")
+	toBurmeseCmd.Flags().BoolVar(&bilingual, "bilingual", false, "also write English-above-Burmese subtitles (.ass and .vtt) and burn those")
+	toBurmeseCmd.Flags().StringVar(&nameTemplate, "name-template", pipeline.DefaultNameTemplate, "output folder and file name; {title} and {id} are replaced, e.g. {id}-{title}")
+	toBurmeseCmd.Flags().StringVar(&sourceList, "list", "", "text file with one YouTube URL, video ID or local file per line")
+	toBurmeseCmd.Flags().IntVarP(&jobs, "jobs", "j", 1, "number of videos processed at the same time")
+	toBurmeseCmd.Flags().IntVar(&download.MaxHeight, "max-height", 0, "highest video resolution to download, e.g. 720 (0 = best available)")
+	toBurmeseCmd.Flags().StringVar(&download.Codec, "codec", download.Codec, "preferred video codec: avc1, vp9 or av01 (empty = any)")
+	toBurmeseCmd.Flags().StringVar(&download.Container, "container", download.Container, "container for downloaded and output videos: mp4 or mkv")
+	toBurmeseCmd.Flags().BoolVar(&download.AudioOnly, "audio-only", false, "download only the audio; produces transcripts, subtitles and Burmese audio but no video")
+	toBurmeseCmd.Flags().IntVar(&download.Retries, "retries", download.Retries, "retries per failed download request, with exponential backoff")
+	toBurmeseCmd.Flags().StringVar(&captions, "captions", string(pipeline.DefaultCaptionMode), "English segments from: whisper (the old default), youtube (uploader captions, else whisper; the default) or youtube-auto (also auto-generated captions)")
+	toBurmeseCmd.Flags().StringVar(&targetSubs, "target-captions", "ask", "use the uploader's captions in the target language instead of translating: ask, yes or no")
+	toBurmeseCmd.Flags().IntVar(&translation.Retries, "translate-retries", translation.Retries, "retries per failed translation chunk, with exponential backoff")
+	toBurmeseCmd.Flags().StringVar(&onTranslateError, "on-translate-error", string(translation.OnError), "when a chunk still fails: fail, skip (leave it out) or keep-source (keep the English text)")
+	toBurmeseCmd.Flags().StringVar(&fromStage, "from-stage", "", "rerun this stage and every later one, even if up to date (download, transcribe, translate, layout, synthesize, merge, burn)")
+	toBurmeseCmd.Flags().StringVar(&onlyStage, "only-stage", "", "run just this stage, reusing the outputs of earlier runs")
+	toBurmeseCmd.MarkFlagsMutuallyExclusive("from-stage", "only-stage")
+	addLanguageFlags(toBurmeseCmd)
+	addTranscriberFlags(toBurmeseCmd)
+	addTranslatorFlags(toBurmeseCmd)
+	addSynthesizerFlags(toBurmeseCmd)
 	rootCmd.AddCommand(toBurmeseCmd)
 }
 
-func video() {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
+func video(cmd *cobra.Command, sources []string) {
+	// Load .env file (only required when no source is given on the command line)
+	if err := godotenv.Load(); err != nil && len(sources) == 0 && sourceList == "" {
 		fmt.Println("❌ Failed to load .env file:", err)
 		return
 	}
 
-	// Get YouTube URL from environment
-	youtubeURL := os.Getenv("DOWNLOAD_YOUTUBE_URL")
-	if youtubeURL == "" {
-		fmt.Println("❌ DOWNLOAD_YOUTUBE_URL not set in .env file")
-		return
-	}
-
-	// Get video info to create output directory based on title
-	client := &youtube.Client{}
-	videoInfo, err := client.GetVideo(youtubeURL)
-	if err != nil {
-		fmt.Println("❌ Failed to get video info:", err)
-		return
+	if sourceList != "" {
+		listed, err := pipeline.ReadSourceList(sourceList)
+		if err != nil {
+			fmt.Println("❌ Failed to read source list:", err)
+			return
+		}
+		sources = append(sources, listed...)
 	}
 
-	// Create output directory based on video title
-	baseName := sanitizeFileName(videoInfo.Title)
-	outputDir := filepath.Join("ToBurmeseVideoOutput", baseName)
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		fmt.Println("❌ Failed to create output directory:", err)
-		return
+	// Fall back to the YouTube URL from environment
+	if len(sources) == 0 {
+		youtubeURL := os.Getenv("DOWNLOAD_YOUTUBE_URL")
+		if youtubeURL == "" {
+			fmt.Println("❌ DOWNLOAD_YOUTUBE_URL not set in .env file")
+			return
+		}
+		sources = []string{youtubeURL}
 	}
-	fmt.Printf("📁 Output directory: %s\n", outputDir)
 
-	// Step 1: Download video
-	err = videoDownloadProcess(youtubeURL, videoInfo, outputDir, baseName)
+	opts, err := pipelineOptions(cmd)
 	if err != nil {
-		fmt.Println("❌ Download Error:", err)
+		fmt.Println("❌", err)
 		return
 	}
+	if opts.Memory != nil {
+		defer opts.Memory.Close()
+	}
 
-	// File names based on video name (all inside baseFile folder)
-	videoFile := filepath.Join(outputDir, baseName+".mp4")
-	englishFile := filepath.Join(outputDir, baseName+"_english.txt")
-	burmeseFile := filepath.Join(outputDir, baseName+"_burmese.txt")
-	burmeseAudio := filepath.Join(outputDir, baseName+"_burmese.mp3")
-	outputVideo := filepath.Join(outputDir, baseName+"_burmese.mp4")
+	// Ctrl+C cancels the running stages
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Step 2: Speech-to-Text (Whisper)
-	fmt.Println("\n🎤 Speech-to-Text ဆောင်ရွက်နေသည်...")
-	_, err = speechToText(videoFile, englishFile)
-	if err != nil {
-		fmt.Println("❌ Error:", err)
-		return
+	// Playlists and channels become one entry per video; the ones that cannot be
+	// listed are reported with the others
+	sources, failed := pipeline.ExpandSources(ctx, nil, sources)
+	if len(sources) > 1 {
+		fmt.Printf("📋 %d videos, %d at a time\n", len(sources), jobs)
 	}
-	fmt.Printf("✅ အင်္ဂလိပ်စာ saved to: %s\n\n", englishFile)
 
-	// Step 3: Translation (English → Burmese)
-	fmt.Println("🔤 မြန်မာစာ အဘိဒ္ဒာန ဆောင်ရွက်နေသည်...")
-	englishContent, err := os.ReadFile(englishFile)
-	if err != nil {
-		fmt.Println("❌ Error reading:", err)
+	results := append(failed, pipeline.RunBatch(ctx, opts, sources, jobs)...)
+	if len(results) == 1 {
+		printResult(results[0])
 		return
 	}
-	_, err = translateToBurmese(string(englishContent), burmeseFile)
-	if err != nil {
-		fmt.Println("❌ Error:", err)
-		return
-	}
-	fmt.Printf("✅ မြန်မာစာ saved to: %s\n\n", burmeseFile)
+	printBatchSummary(results)
+}
 
-	// Step 4: Text-to-Speech (Burmese)
-	fmt.Println("\n🔊 Burmese TTS ဆောင်ရွက်နေသည်...")
-	err = textToSpeechBurmese(burmeseFile, burmeseAudio)
-	if err != nil {
-		fmt.Println("❌ TTS Error:", err)
+// printResult reports the outcome of a single video
+func printResult(r pipeline.BatchResult) {
+	if r.Err != nil {
+		var stageErr *pipeline.StageError
+		if errors.As(r.Err, &stageErr) {
+			fmt.Printf("❌ %s Error: %v\n", stageErr.Stage, stageErr.Err)
+		} else {
+			fmt.Println("❌ Error:", r.Err)
+		}
 		return
 	}
-
-	// Step 5: Merge audio with video
-	fmt.Println("\n🎬 Video နှင့် Audio ပေါင်းစပ်နေသည်...")
-	err = mergeAudioWithVideo(videoFile, burmeseAudio, outputVideo)
-	if err != nil {
-		fmt.Println("❌ Merge Error:", err)
-		return
+	fmt.Printf("\n🎉 Complete! Final output: %s\n", finalOutput(r.Result))
+	if r.Result.Translation.Untranslated > 0 {
+		fmt.Printf("⚠️ %s\n", r.Result.Translation)
 	}
-
-	fmt.Printf("\n🎉 Complete! Final video: %s\n", outputVideo)
 }
 
-func videoDownloadProcess(youtubeURL string, videoInfo *youtube.Video, outputDir, baseName string) error {
-	// Step 1: YouTube ဒေါင်းလုပ်ခြင်း
-	fmt.Println("🎥 YouTube ဒေါင်းလုပ်နေသည်...")
-	err := downloadYouTube(videoInfo, outputDir, baseName)
-	if err != nil {
-		return err
+// printBatchSummary prints a table of every batch entry and its outcome
+func printBatchSummary(results []pipeline.BatchResult) {
+	failed := 0
+	fmt.Println("\n📊 Summary")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tSTATUS\tVIDEO\tTIME\tOUTPUT / ERROR")
+	for i, r := range results {
+		video := r.Source
+		if r.Result != nil {
+			video = r.Result.Title
+		}
+		status, detail := "✅ ok", ""
+		if r.Err != nil {
+			failed++
+			status, detail = "❌ failed", r.Err.Error()
+		} else {
+			detail = finalOutput(r.Result)
+			if t := r.Result.Translation; t.Untranslated > 0 {
+				status = "⚠️ partial"
+				detail += " (" + t.String() + ")"
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, status, video, r.Duration.Round(time.Second), detail)
 	}
-	fmt.Printf("✅ Video saved: %s/%s.mp4\n", outputDir, baseName)
-	return nil
+	w.Flush()
+	fmt.Printf("\n%d succeeded, %d failed\n", len(results)-failed, failed)
 }
 
-// YouTube ဒေါင်းလုပ်ခြင်း
-func downloadYouTube(video *youtube.Video, outputDir, baseName string) error {
-	client := &youtube.Client{}
+// finalOutput is the last file a successful run produced
+func finalOutput(res *pipeline.Result) string {
+	if res.SubtitledVideo != "" {
+		return res.SubtitledVideo
+	}
+	return res.TargetAudio
+}
 
-	fmt.Printf("📹 Title: %s\n", video.Title)
+var askMu sync.Mutex // one question at a time when videos run concurrently
 
-	formats := video.Formats.WithAudioChannels()
-	if len(formats) == 0 {
-		return fmt.Errorf("audio format မရှိ")
+// askTargetCaptions asks on the terminal whether to use an existing target-language caption
+// track. Without a terminal the video is translated as usual.
+func askTargetCaptions(title, track string) bool {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
 	}
+	askMu.Lock()
+	defer askMu.Unlock()
 
-	// ပထမ audio format ရွေးချယ်ခြင်း
-	format := &formats[0]
-	totalSize := format.ContentLength
-	fmt.Printf("📦 Size: %.2f MB\n", float64(totalSize)/(1024*1024))
+	fmt.Printf("💬 %q has %s captions. Use them instead of translating? [y/N] ", title, track)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
 
-	stream, _, err := client.GetStream(video, format)
+// pipelineOptions builds pipeline options from the command-line flags
+func pipelineOptions(cmd *cobra.Command) (pipeline.Options, error) {
+	source, target, err := languages()
 	if err != nil {
-		return err
+		return pipeline.Options{}, err
 	}
-	defer stream.Close()
-
-	// File သိမ်းဆည်းခြင်း - save to outputDir
-	outputFile := filepath.Join(outputDir, baseName+".mp4")
-	out, err := os.Create(outputFile)
+	transcriber, err := newTranscriber()
 	if err != nil {
-		return err
+		return pipeline.Options{}, err
 	}
-	defer out.Close()
-
-	// Progress tracking
-	var downloaded int64
-	buf := make([]byte, 32*1024)
-	for {
-		n, err := stream.Read(buf)
-		if n > 0 {
-			_, writeErr := out.Write(buf[:n])
-			if writeErr != nil {
-				return writeErr
-			}
-			downloaded += int64(n)
-			if totalSize > 0 {
-				percent := float64(downloaded) / float64(totalSize) * 100
-				fmt.Printf("\r⬇️  Downloading: %.1f%% (%.2f MB / %.2f MB)", percent, float64(downloaded)/(1024*1024), float64(totalSize)/(1024*1024))
-			} else {
-				fmt.Printf("\r⬇️  Downloaded: %.2f MB", float64(downloaded)/(1024*1024))
-			}
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-	}
-	fmt.Println() // New line after progress
-
-	return nil
-}
-
-// Sanitize file name - remove special characters
-func sanitizeFileName(name string) string {
-	// Replace spaces and special chars with underscores
-	result := strings.Map(func(r rune) rune {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
-			return r
-		}
-		if r == ' ' {
-			return '_'
-		}
-		return -1 // remove other characters
-	}, name)
-	// Limit length
-	if len(result) > 50 {
-		result = result[:50]
-	}
-	return result
-}
-
-// getProjectDir returns the current working directory
-func getProjectDir() string {
-	dir, err := os.Getwd()
+	translator, err := newTranslator()
 	if err != nil {
-		return "."
+		return pipeline.Options{}, err
 	}
-	return dir
-}
-
-// Speech-to-Text (Whisper အသုံးပြုခြင်း)
-func speechToText(audioFile, outputFile string) (string, error) {
-	// Whisper CLI သုံးခြင်း (Python Whisper ထည့်သွင်းရမည်)
-	whisperPath := filepath.Join(filepath.Dir(os.Args[0]), "..", ".venv", "bin", "whisper")
-	// If running with go run, use current working directory
-	if _, err := os.Stat(whisperPath); os.IsNotExist(err) {
-		whisperPath = filepath.Join(getProjectDir(), ".venv", "bin", "whisper")
+	synthesizer, voice, err := newSynthesizer()
+	if err != nil {
+		return pipeline.Options{}, err
 	}
-
-	// Get the output directory from the outputFile path
-	outputDir := filepath.Dir(outputFile)
-	cmd := exec.Command(whisperPath, audioFile, "--language", "en", "--output_format", "txt", "--output_dir", outputDir)
-
-	// Pipe stdout and stderr to show progress in real-time
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
+	glossary, err := loadGlossary()
 	if err != nil {
-		return "", fmt.Errorf("whisper error: %w", err)
+		return pipeline.Options{}, err
 	}
-
-	// Output text file ဖတ်ခြင်း - whisper creates file based on input filename
-	baseNameOnly := strings.TrimSuffix(filepath.Base(audioFile), filepath.Ext(audioFile))
-	txtFile := filepath.Join(outputDir, baseNameOnly+".txt")
-	text, err := os.ReadFile(txtFile)
+	memory, err := openMemory()
 	if err != nil {
-		return "", err
+		return pipeline.Options{}, err
 	}
 
-	// Save to output file (rename to _english.txt)
-	if err := os.WriteFile(outputFile, text, 0644); err != nil {
-		return "", fmt.Errorf("failed to write %s: %w", outputFile, err)
+	// Subtitle font follows the target language unless chosen explicitly
+	style := subtitleStyle
+	if !cmd.Flags().Changed("subtitle-font-name") {
+		style.FontName = target.FontName
 	}
 
-	return string(text), nil
-}
-
-// Translation (Google Translate API သုံးခြင်း)
-func translateToBurmese(text, outputFile string) (string, error) {
-	// Google Translate API (အခမ်းအံ့ option)
-	// ၎င်းအတွက် API key လိုအပ်ပါသည်
-
-	// Alternative: Python subprocess သုံးခြင်း (deep-translator အသုံးပြု - အခမဲ့)
-	pythonPath := filepath.Join(filepath.Dir(os.Args[0]), "..", ".venv", "bin", "python3")
-	// If running with go run, use current working directory
-	if _, err := os.Stat(pythonPath); os.IsNotExist(err) {
-		pythonPath = filepath.Join(getProjectDir(), ".venv", "bin", "python3")
+	opts := pipeline.Options{
+		SourceLanguage: source,
+		TargetLanguage: target,
+		Transcriber:    transcriber,
+		Translator:     translator,
+		Synthesizer:    synthesizer,
+		Voice:          voice,
+		Glossary:       glossary,
+		Memory:         memory,
+		NameTemplate:   nameTemplate,
+		SubtitleStyle:  style,
+		Bilingual:      bilingual,
+		Download:       download,
+		Progress:       os.Stdout,
 	}
 
-	// Split text into chunks of max 4500 characters (under 5000 limit)
-	// Split on sentence boundaries where possible
-	chunks := splitTextIntoChunks(text, 4500)
-	var translatedChunks []string
-
-	for i, chunk := range chunks {
-		fmt.Printf("  Translating chunk %d/%d...\n", i+1, len(chunks))
-
-		cmd := exec.Command(pythonPath, "-c", `
-import sys
-from deep_translator import GoogleTranslator
-translator = GoogleTranslator(source='en', target='my')
-text = sys.stdin.read()
-result = translator.translate(text)
-print(result)
-`)
-
-		// Stream stderr to show progress in real-time
-		cmd.Stderr = os.Stderr
-
-		// Set up stdin pipe to send text
-		stdin, err := cmd.StdinPipe()
-		if err != nil {
-			return "", fmt.Errorf("stdin pipe error: %w", err)
-		}
-
-		// Capture stdout for the result
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return "", fmt.Errorf("stdout pipe error: %w", err)
-		}
-
-		if err := cmd.Start(); err != nil {
-			return "", fmt.Errorf("start error: %w", err)
-		}
-
-		// Write text to stdin and close
-		_, err = stdin.Write([]byte(chunk))
-		if err != nil {
-			return "", fmt.Errorf("write error: %w", err)
-		}
-		stdin.Close()
-
-		// Read the output
-		output, err := io.ReadAll(stdout)
-		if err != nil {
-			return "", fmt.Errorf("read error: %w", err)
-		}
-
-		if err := cmd.Wait(); err != nil {
-			// Fallback: အင်္ဂလိပ်စာ ပြန်ပေးခြင်း
-			translatedChunks = append(translatedChunks, "Translation error - "+chunk)
-			continue
-		}
-
-		translatedChunks = append(translatedChunks, strings.TrimSpace(string(output)))
+	if subtitleLayout.Unit, err = subtitle.ParseWidthUnit(layoutUnit); err != nil {
+		return opts, err
 	}
+	opts.SubtitleLayout = subtitleLayout
 
-	result := strings.Join(translatedChunks, " ")
-
-	// Save to output file
-	if err := os.WriteFile(outputFile, []byte(result), 0644); err != nil {
-		return "", fmt.Errorf("failed to write %s: %w", outputFile, err)
+	if translation.OnError, err = pipeline.ParseTranslateErrorPolicy(onTranslateError); err != nil {
+		return opts, err
 	}
+	opts.Translation = translation
 
-	return result, nil
-}
-
-// splitTextIntoChunks splits text into chunks of maxSize characters
-// trying to split on sentence boundaries
-func splitTextIntoChunks(text string, maxSize int) []string {
-	if len(text) <= maxSize {
-		return []string{text}
+	if opts.Captions, err = pipeline.ParseCaptionMode(captions); err != nil {
+		return opts, err
 	}
-
-	var chunks []string
-	remaining := text
-
-	for len(remaining) > 0 {
-		if len(remaining) <= maxSize {
-			chunks = append(chunks, remaining)
-			break
-		}
-
-		// Find a good split point (end of sentence) within maxSize
-		chunk := remaining[:maxSize]
-		splitPoint := maxSize
-
-		// Try to find sentence ending (.!?) followed by space
-		for i := maxSize - 1; i > maxSize/2; i-- {
-			if (chunk[i] == '.' || chunk[i] == '!' || chunk[i] == '?') &&
-				(i+1 >= len(chunk) || chunk[i+1] == ' ' || chunk[i+1] == '\n') {
-				splitPoint = i + 1
-				break
-			}
-		}
-
-		// If no sentence boundary found, try to split on space
-		if splitPoint == maxSize {
-			for i := maxSize - 1; i > maxSize/2; i-- {
-				if chunk[i] == ' ' {
-					splitPoint = i + 1
-					break
-				}
-			}
-		}
-
-		chunks = append(chunks, strings.TrimSpace(remaining[:splitPoint]))
-		remaining = strings.TrimSpace(remaining[splitPoint:])
-	}
-
-	return chunks
-}
-
-// Result များကို File သိမ်းဆည်းခြင်း
-func saveResults(english, burmese, filename string) error {
-	content := fmt.Sprintf("=== YouTube Speech-to-Text Results ===\n\nEnglish:\n%s\n\nBurmese:\n%s\n", english, burmese)
-	return os.WriteFile(filename, []byte(content), 0644)
-}
-
-// getVoiceName returns the Edge TTS voice based on VOICE_PRESENTER env value
-// Options: men/thiha -> male voice, women/girl -> female voice
-// Default: men (male voice)
-func getVoiceName() string {
-	presenter := strings.ToLower(os.Getenv("VOICE_PRESENTER"))
-	switch presenter {
-	case "women", "girl":
-		return "my-MM-NilarNeural" // အမျိုးသမီးအသံ
-	case "men", "thiha", "":
-		return "my-MM-ThihaNeural" // အမျိုးသားအသံ
+	switch targetSubs {
+	case "yes":
+		opts.UseTargetCaptions = func(string, string) bool { return true }
+	case "no":
+	case "ask":
+		opts.UseTargetCaptions = askTargetCaptions
 	default:
-		return "my-MM-ThihaNeural" // default: အမျိုးသားအသံ
-	}
-}
-
-// Text-to-Speech for Burmese (Edge TTS အသုံးပြု - အရည်အသွေးပိုကောင်း)
-func textToSpeechBurmese(textFile, outputAudio string) error {
-	edgeTTSPath := filepath.Join(filepath.Dir(os.Args[0]), "..", ".venv", "bin", "edge-tts")
-	if _, err := os.Stat(edgeTTSPath); os.IsNotExist(err) {
-		edgeTTSPath = filepath.Join(getProjectDir(), ".venv", "bin", "edge-tts")
+		return opts, fmt.Errorf("unknown --target-captions value %q (want ask, yes or no)", targetSubs)
 	}
 
-	voiceName := getVoiceName()
-	fmt.Printf("🔊 Generating Burmese audio with Edge TTS (voice: %s)...\n", voiceName)
-
-	// Use Edge TTS with Myanmar voice
-	// --rate: speech speed (-50% to +100%), --pitch: voice pitch
-	cmd := exec.Command(edgeTTSPath,
-		"--voice", voiceName,
-		"--file", textFile,
-		"--write-media", outputAudio,
-		"--rate=-10%", // slightly slower for clarity
-	)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("edge-tts error: %w", err)
+	if fromStage != "" {
+		if opts.FromStage, err = pipeline.ParseStage(fromStage); err != nil {
+			return opts, err
+		}
 	}
-
-	fmt.Printf("✅ Audio saved to %s\n", outputAudio)
-	return nil
-}
-
-// Merge Burmese audio with video (ffmpeg အသုံးပြု)
-func mergeAudioWithVideo(videoFile, audioFile, outputFile string) error {
-	fmt.Println("🎬 Merging Burmese audio with video...")
-
-	// ffmpeg -i video.mp4 -i burmese_audio.mp3 -c:v copy -map 0:v:0 -map 1:a:0 output.mp4
-	cmd := exec.Command("ffmpeg", "-y",
-		"-i", videoFile,
-		"-i", audioFile,
-		"-c:v", "copy",
-		"-map", "0:v:0",
-		"-map", "1:a:0",
-		"-shortest",
-		outputFile,
-	)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("ffmpeg error: %w", err)
+	if onlyStage != "" {
+		if opts.OnlyStage, err = pipeline.ParseStage(onlyStage); err != nil {
+			return opts, err
+		}
 	}
-
-	fmt.Printf("✅ Video with Burmese audio saved to: %s\n", outputFile)
-	return nil
+	return opts, nil
 }
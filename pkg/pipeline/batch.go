@@ -0,0 +1,231 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// BatchResult is the outcome of one entry of a batch
+type BatchResult struct {
+	Source   string
+	Result   *Result // nil if the run failed before the output directory was known
+	Err      error
+	Duration time.Duration
+}
+
+// RunBatch runs the pipeline for every source with at most jobs running at once.
+// A failed entry does not stop the others. Results keep the order of sources.
+func RunBatch(ctx context.Context, opts Options, sources []string, jobs int) []BatchResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	results := make([]BatchResult, len(sources))
+	sem := make(chan struct{}, jobs)
+	var mu sync.Mutex // serializes progress output from concurrent jobs
+	var wg sync.WaitGroup
+
+	for i, source := range sources {
+		results[i].Source = source
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, source string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			jobOpts := opts
+			jobOpts.Source = source
+			jobOpts.Progress = progress
+			if len(sources) > 1 {
+				jobOpts.Progress = &prefixWriter{w: progress, mu: &mu, prefix: fmt.Sprintf("[%d/%d] ", i+1, len(sources)), lineStart: true}
+			}
+
+			start := time.Now()
+			p, err := New(jobOpts)
+			if err == nil {
+				results[i].Result, err = p.Run(ctx)
+			}
+			results[i].Err = err
+			results[i].Duration = time.Since(start)
+		}(i, source)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// prefixWriter tags each progress line with its batch entry
+type prefixWriter struct {
+	w         io.Writer
+	mu        *sync.Mutex
+	prefix    string
+	lineStart bool
+}
+
+func (pw *prefixWriter) Write(b []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	var out bytes.Buffer
+	for _, c := range b {
+		if pw.lineStart && c != '\n' && c != '\r' {
+			out.WriteString(pw.prefix)
+			pw.lineStart = false
+		}
+		out.WriteByte(c)
+		if c == '\n' || c == '\r' {
+			pw.lineStart = true
+		}
+	}
+	if _, err := pw.w.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// ExpandSources replaces playlist and channel URLs with the URLs of their videos
+// and drops duplicates. Other sources (video URLs, IDs, local files) pass through.
+// A playlist or channel that cannot be listed (private, deleted) is returned as a
+// failed BatchResult and the others are still expanded.
+func ExpandSources(ctx context.Context, client *youtube.Client, sources []string) ([]string, []BatchResult) {
+	if client == nil {
+		client = &youtube.Client{}
+	}
+
+	var expanded []string
+	var failed []BatchResult
+	seen := map[string]bool{}
+	add := func(source string) {
+		if !seen[source] {
+			seen[source] = true
+			expanded = append(expanded, source)
+		}
+	}
+
+	for _, source := range sources {
+		playlistID, ok := playlistSource(source)
+		if !ok && channelNameRe.MatchString(source) {
+			channelID, err := resolveChannelID(ctx, client, source)
+			if err != nil {
+				failed = append(failed, BatchResult{Source: source, Err: fmt.Errorf("channel %s: %w", source, err)})
+				continue
+			}
+			playlistID, ok = "UU"+strings.TrimPrefix(channelID, "UC"), true
+		}
+		if !ok {
+			add(source)
+			continue
+		}
+
+		playlist, err := client.GetPlaylistContext(ctx, playlistID)
+		if err != nil {
+			failed = append(failed, BatchResult{Source: source, Err: fmt.Errorf("playlist %s: %w", source, err)})
+			continue
+		}
+		for _, entry := range playlist.Videos {
+			add("https://www.youtube.com/watch?v=" + entry.ID)
+		}
+	}
+	return expanded, failed
+}
+
+// playlistSource returns the playlist to expand for a playlist URL
+// (list= without a single video) or a channel URL (its uploads playlist)
+func playlistSource(source string) (string, bool) {
+	if isLocalFile(source) || !strings.Contains(source, "youtube.com/") {
+		return "", false
+	}
+
+	// youtube.com/channel/UCxxxx → uploads playlist UUxxxx
+	if _, rest, ok := strings.Cut(source, "/channel/UC"); ok {
+		id, _, _ := strings.Cut(rest, "/")
+		id, _, _ = strings.Cut(id, "?")
+		return "UU" + id, true
+	}
+
+	if strings.Contains(source, "list=") && !strings.Contains(source, "v=") {
+		return source, true
+	}
+	return "", false
+}
+
+// channelNameRe matches channel URLs by handle (/@name) or legacy name (/c/name,
+// /user/name), which do not contain the channel ID
+var channelNameRe = regexp.MustCompile(`youtube\.com/(@|c/|user/)[^/?#]+`)
+
+// channelIDRe finds the channel ID in a channel page's canonical link
+var channelIDRe = regexp.MustCompile(`youtube\.com/channel/(UC[0-9A-Za-z_-]{22})`)
+
+// resolveChannelID looks up the channel ID of a channel URL by handle or name
+func resolveChannelID(ctx context.Context, client *youtube.Client, source string) (string, error) {
+	url := "https://www." + channelNameRe.FindString(source)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("channel page: %s", resp.Status)
+	}
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", err
+	}
+	m := channelIDRe.FindSubmatch(page)
+	if m == nil {
+		return "", fmt.Errorf("no channel ID on %s; use the youtube.com/channel/UC… URL instead", url)
+	}
+	return string(m[1]), nil
+}
+
+// ReadSourceList reads sources from a text file, one per line.
+// Blank lines and lines starting with # are ignored.
+func ReadSourceList(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sources []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sources = append(sources, line)
+	}
+	return sources, scanner.Err()
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/banyar-sithu/video/pkg/subtitle"
+)
+
+// Bilingual subtitles show the source text on top in a smaller style and the
+// translation below it, in one cue so players keep the two together
+
+// bilingualSourceScale is the source text's size relative to the translation's
+const bilingualSourceScale = 0.7
+
+// ASS style names; WebVTT cues use the classes "source" and "target"
+const (
+	bilingualSourceStyle = "Source"
+	bilingualTargetStyle = "Target"
+)
+
+// untranslatedStyle keeps segments left in the source language out of the cues the
+// layout merges translated segments into
+const untranslatedStyle = "Untranslated"
+
+// BilingualTrack lays out the source and translated segments like the subtitles (split,
+// wrapped and timed for reading) and puts above each translated cue the source cues
+// spoken during it, capped at the layout's line count. Segments left in the source
+// language show once.
+func BilingualTrack(source, target []Segment, sourceLang, targetLang Language, style SubtitleStyle, layout subtitle.Layout) *subtitle.Track {
+	sourceLayout := layout
+	sourceLayout.FontSize = math.Round(float64(style.FontSize) * bilingualSourceScale)
+
+	track := &subtitle.Track{
+		Styles: []subtitle.Style{
+			bilingualStyle(bilingualTargetStyle, targetLang.FontName, float64(style.FontSize), "&H00FFFFFF", style),
+			bilingualStyle(bilingualSourceStyle, sourceLang.FontName, sourceLayout.FontSize, "&H00E0E0E0", style),
+		},
+		VTTBlocks: []string{"STYLE\n::cue(.source) {\n  font-size: 70%;\n  color: #e0e0e0;\n}"},
+	}
+
+	cues := make([]subtitle.Cue, len(target))
+	for i, t := range target {
+		cues[i] = subtitle.Cue{Start: t.Start, End: t.End, Text: subtitle.Escape(targetLang.NormalizeText(t.Text)), Style: bilingualTargetStyle}
+		if t.Untranslated {
+			cues[i].Style = untranslatedStyle
+		}
+	}
+	cues, _ = layout.Apply(cues)
+	sourceCues := make([]subtitle.Cue, len(source))
+	for i, s := range source {
+		sourceCues[i] = subtitle.Cue{Start: s.Start, End: s.End, Text: subtitle.Escape(sourceLang.NormalizeText(s.Text))}
+	}
+	sourceCues, _ = sourceLayout.Apply(sourceCues)
+
+	for _, c := range cues {
+		untranslated := c.Style == untranslatedStyle
+		c.Style = bilingualTargetStyle
+		if src := alignedSource(sourceCues, c.Start, c.End); src != "" && !untranslated {
+			lines := sourceLines(sourceLayout, src)
+			// {\r...} switches the ASS style; <c.class> is WebVTT's. Each writer drops the other.
+			c.Text = `{\r` + bilingualSourceStyle + `}<c.source>` + subtitle.Escape(strings.Join(lines, "\n")) + "</c>\n" +
+				`{\r}<c.target>` + c.Text + "</c>"
+		}
+		track.Cues = append(track.Cues, c)
+	}
+	return track
+}
+
+// sourceLines wraps the source markup to at most the layout's line count, ending with an
+// ellipsis when some of it is cut
+func sourceLines(layout subtitle.Layout, text string) []string {
+	maxLines := layout.MaxLines
+	if maxLines <= 0 {
+		maxLines = subtitle.DefaultLayout.MaxLines
+	}
+	lines := layout.Wrap(text)
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] = strings.TrimRight(lines[maxLines-1], " ") + "…"
+	}
+	return lines
+}
+
+// alignedSource joins the source cues whose midpoint falls in [start, end), or the one
+// overlapping it most if none does
+func alignedSource(source []subtitle.Cue, start, end time.Duration) string {
+	var texts []string
+	best, bestOverlap := -1, time.Duration(0)
+	for i, s := range source {
+		if mid := s.Start + (s.End-s.Start)/2; mid >= start && mid < end {
+			texts = append(texts, s.Text)
+		}
+		if overlap := min(s.End, end) - max(s.Start, start); overlap > bestOverlap {
+			best, bestOverlap = i, overlap
+		}
+	}
+	if len(texts) == 0 && best >= 0 {
+		texts = append(texts, source[best].Text)
+	}
+	return strings.Join(texts, " ")
+}
+
+func bilingualStyle(name, font string, size float64, colour string, style SubtitleStyle) subtitle.Style {
+	s := subtitle.DefaultStyle
+	s.Name, s.FontName, s.FontSize = name, font, size
+	s.PrimaryColour, s.SecondaryColour = colour, colour
+	s.OutlineColour, s.BackColour = "&H00000000", "&H80000000"
+	s.Outline = float64(style.Outline)
+	s.MarginV = style.MarginV
+	return s
+}
+
+// WriteBilingual writes the bilingual track as ASS (for burning in) and WebVTT
+func WriteBilingual(assFile, vttFile string, track *subtitle.Track) error {
+	if err := subtitle.WriteFile(assFile, track); err != nil {
+		return err
+	}
+	return subtitle.WriteFile(vttFile, track)
+}
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// CaptionMode chooses where the source-language segments come from for YouTube sources
+type CaptionMode string
+
+const (
+	CaptionsWhisper     CaptionMode = "whisper"      // always run the Transcriber
+	CaptionsYouTube     CaptionMode = "youtube"      // uploader-made captions, else the Transcriber
+	CaptionsYouTubeAuto CaptionMode = "youtube-auto" // also accept YouTube's auto-generated captions
+)
+
+// DefaultCaptionMode uses the uploader's captions when there are any, which saves a
+// long transcription, and the Transcriber otherwise
+const DefaultCaptionMode = CaptionsYouTube
+
+// ParseCaptionMode validates a caption mode name
+func ParseCaptionMode(name string) (CaptionMode, error) {
+	switch mode := CaptionMode(name); mode {
+	case CaptionsWhisper, CaptionsYouTube, CaptionsYouTubeAuto:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown caption source %q (want whisper, youtube or youtube-auto)", name)
+}
+
+// FindCaptionTrack returns the caption track for lang (e.g. "en" also matches "en-GB").
+// Uploader-made tracks win over auto-generated ones, which are only used when allowAuto is set.
+func FindCaptionTrack(tracks []youtube.CaptionTrack, lang string, allowAuto bool) *youtube.CaptionTrack {
+	var auto *youtube.CaptionTrack
+	for i, t := range tracks {
+		if t.LanguageCode != lang && !strings.HasPrefix(t.LanguageCode, lang+"-") {
+			continue
+		}
+		if t.Kind != "asr" {
+			return &tracks[i]
+		}
+		if allowAuto && auto == nil {
+			auto = &tracks[i]
+		}
+	}
+	return auto
+}
+
+// describeCaptionTrack identifies a track in manifests and progress output
+func describeCaptionTrack(t *youtube.CaptionTrack) string {
+	kind := "uploader"
+	if t.Kind == "asr" {
+		kind = "auto-generated"
+	}
+	return fmt.Sprintf("youtube captions %s (%s)", t.LanguageCode, kind)
+}
+
+// json3Captions matches YouTube's timedtext "json3" format
+type json3Captions struct {
+	Events []struct {
+		StartMs    int64 `json:"tStartMs"`
+		DurationMs int64 `json:"dDurationMs"`
+		Segs       []struct {
+			UTF8 string `json:"utf8"`
+		} `json:"segs"`
+	} `json:"events"`
+}
+
+// FetchCaptions downloads a caption track and converts it to timed segments
+func FetchCaptions(ctx context.Context, client *youtube.Client, track *youtube.CaptionTrack) ([]Segment, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, track.BaseURL+"&fmt=json3", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("caption download error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("caption download error: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var parsed json3Captions
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse captions: %w", err)
+	}
+	return parsed.toSegments(), nil
+}
+
+// toSegments drops empty events (line breaks, styling) and trims overlaps, which
+// auto-generated captions have, so every segment ends before the next one starts
+func (c json3Captions) toSegments() []Segment {
+	var segments []Segment
+	for _, e := range c.Events {
+		var text strings.Builder
+		for _, s := range e.Segs {
+			text.WriteString(s.UTF8)
+		}
+		t := strings.Join(strings.Fields(text.String()), " ")
+		if t == "" {
+			continue
+		}
+
+		start := time.Duration(e.StartMs) * time.Millisecond
+		if n := len(segments); n > 0 && segments[n-1].End > start {
+			segments[n-1].End = start
+		}
+		segments = append(segments, Segment{
+			ID:    len(segments) + 1,
+			Start: start,
+			End:   start + time.Duration(e.DurationMs)*time.Millisecond,
+			Text:  t,
+		})
+	}
+	return segments
+}
@@ -0,0 +1,206 @@
+package pipeline
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextBudget limits how much text goes into one translator request.
+// Count measures a text in the backend's unit (runes, tokens, ...).
+type TextBudget struct {
+	Max   int
+	Count func(string) int
+}
+
+// Budgeted is implemented by translators with their own request limits
+type Budgeted interface {
+	Budget() TextBudget
+}
+
+// DefaultBudget keeps each request under Google Translate's 5000 character limit
+var DefaultBudget = TextBudget{Max: 4500, Count: utf8.RuneCountInString}
+
+// budgetOf returns the translator's budget, or DefaultBudget
+func budgetOf(translator Translator) TextBudget {
+	if b, ok := translator.(Budgeted); ok {
+		budget := b.Budget()
+		if budget.Max > 0 && budget.Count != nil {
+			return budget
+		}
+	}
+	return DefaultBudget
+}
+
+// EstimateTokens approximates LLM tokens: about four characters per token for
+// space-separated scripts, and one token per character elsewhere (Burmese, CJK, Thai)
+func EstimateTokens(text string) int {
+	tokens, latin := 0, 0
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+			latin++
+		case unicode.IsMark(r):
+			// counted with its base character
+		default:
+			tokens++
+		}
+	}
+	return tokens + (latin+3)/4
+}
+
+// chunkPiece is the text of one segment, or part of it when the segment alone is over budget
+type chunkPiece struct {
+	ID   int // Segment.ID the text belongs to
+	Text string
+}
+
+// buildChunks groups consecutive segments into requests that fit the budget. Segments
+// over budget are split on sentence boundaries (sentenceEnd, see TranslateOptions);
+// every piece keeps its segment ID so the translations can be put back together with
+// the original timing.
+func buildChunks(segments []Segment, budget TextBudget, sentenceEnd string) [][]chunkPiece {
+	var chunks [][]chunkPiece
+	var current []chunkPiece
+	size := 0
+
+	add := func(p chunkPiece) {
+		n := budget.Count(p.Text)
+		if len(current) > 0 && size+n > budget.Max {
+			chunks = append(chunks, current)
+			current, size = nil, 0
+		}
+		current = append(current, p)
+		size += n
+	}
+
+	for _, s := range segments {
+		for _, text := range splitTextIntoChunks(s.Text, budget, sentenceEnd) {
+			add(chunkPiece{ID: s.ID, Text: text})
+		}
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// buildSegmentChunks groups whole segments into chunks within the budget, for
+// translators that answer per segment ID. A segment over the budget gets a chunk of its own.
+func buildSegmentChunks(segments []Segment, budget TextBudget) [][]chunkPiece {
+	var chunks [][]chunkPiece
+	var current []chunkPiece
+	size := 0
+	for _, s := range segments {
+		n := budget.Count(s.Text)
+		if len(current) > 0 && size+n > budget.Max {
+			chunks = append(chunks, current)
+			current, size = nil, 0
+		}
+		current = append(current, chunkPiece{ID: s.ID, Text: strings.TrimSpace(s.Text)})
+		size += n
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// splitTextIntoChunks splits text into pieces within the budget, preferring sentence
+// ends, then clause marks, then spaces. It always cuts between characters, never inside
+// a UTF-8 sequence or between a letter and its combining marks.
+func splitTextIntoChunks(text string, budget TextBudget, sentenceEnd string) []string {
+	var chunks []string
+	remaining := strings.TrimSpace(text)
+
+	for remaining != "" {
+		if budget.Count(remaining) <= budget.Max {
+			chunks = append(chunks, remaining)
+			break
+		}
+
+		// Longest prefix (in whole runes) that fits the budget
+		var offsets []int
+		for i := range remaining {
+			offsets = append(offsets, i)
+		}
+		n := sort.Search(len(offsets), func(k int) bool {
+			return budget.Count(remaining[:offsets[k]]) > budget.Max
+		})
+		limit := offsets[n-1]
+		if limit == 0 {
+			// Not even one character fits: send it anyway
+			_, limit = utf8.DecodeRuneInString(remaining)
+		}
+
+		splitPoint := findSplitPoint(remaining, limit, sentenceEnd)
+		chunks = append(chunks, strings.TrimSpace(remaining[:splitPoint]))
+		remaining = strings.TrimSpace(remaining[splitPoint:])
+	}
+	return chunks
+}
+
+// findSplitPoint picks the byte offset to cut text at, at most limit, searching the
+// second half of the window for the best kind of boundary
+func findSplitPoint(text string, limit int, sentenceEnd string) int {
+	window := text[:limit]
+	for _, isBoundary := range []func(r rune) bool{sentenceEnder(sentenceEnd), isClauseEnd, unicode.IsSpace} {
+		for i := len(window); i > limit/2; {
+			r, size := utf8.DecodeLastRuneInString(window[:i])
+			if isBoundary(r) && !startsWithMark(text[i:]) && (r >= utf8.RuneSelf || !unicode.IsPunct(r) || startsWithSpace(text[i:])) {
+				return i
+			}
+			i -= size
+		}
+	}
+
+	// No boundary: cut before the last base character so its marks stay with it
+	for i := limit; i > 0; {
+		if !startsWithMark(text[i:]) {
+			return i
+		}
+		_, size := utf8.DecodeLastRuneInString(text[:i])
+		i -= size
+	}
+	return limit
+}
+
+// startsWithSpace is true at the end of text too; ASCII punctuation only ends a
+// sentence before a space, so numbers like "3.5" stay whole
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return s == "" || unicode.IsSpace(r)
+}
+
+func startsWithMark(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsMark(r)
+}
+
+// sentenceEnder returns the test for a language's sentence punctuation (Language.SentenceEnd),
+// or isSentenceEnd when the language is not known
+func sentenceEnder(sentenceEnd string) func(r rune) bool {
+	if sentenceEnd == "" {
+		return isSentenceEnd
+	}
+	return func(r rune) bool { return r == '…' || strings.ContainsRune(sentenceEnd, r) }
+}
+
+// isSentenceEnd covers Latin, Burmese (။), Devanagari (।), Arabic, CJK and ellipsis terminators
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '.', '!', '?', '…', '။', '।', '॥', '؟', '۔', '。', '！', '？', '｡':
+		return true
+	}
+	return false
+}
+
+// isClauseEnd covers commas and semicolons, including the Burmese ၊
+func isClauseEnd(r rune) bool {
+	switch r {
+	case ',', ';', ':', '၊', '、', '，', '；', '،':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitTextIntoChunks(t *testing.T) {
+	runes := func(max int) TextBudget { return TextBudget{Max: max, Count: utf8.RuneCountInString} }
+	tests := []struct {
+		name        string
+		text        string
+		budget      TextBudget
+		sentenceEnd string
+		want        []string
+	}{
+		{"fits", "Short text.", runes(20), "", []string{"Short text."}},
+		{"at sentence end", "First one here. Second one here.", runes(20), "", []string{"First one here.", "Second one here."}},
+		{"at clause", "Well then, something else here", runes(20), "", []string{"Well then,", "something else here"}},
+		{"decimal stays whole", "It costs 3.5 dollars each time", runes(20), "", []string{"It costs 3.5", "dollars each time"}},
+		{"Burmese sentence end", "မင်္ဂလာပါ။ ကျွန်တော် နေကောင်းပါတယ်", runes(15), "။", []string{"မင်္ဂလာပါ။", "ကျွန်တော်", "နေကောင်းပါတယ်"}},
+		{"marks stay with their letter", "ကျွန်ုပ်ကျွန်ုပ်", runes(10), "", []string{"ကျွန်ုပ်", "ကျွန်ုပ်"}},
+		{"language without that end", "Stop. Go on now and then", runes(20), "。", []string{"Stop. Go on now and", "then"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitTextIntoChunks(tt.text, tt.budget, tt.sentenceEnd)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("splitTextIntoChunks(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			for _, c := range got {
+				if !utf8.ValidString(c) || tt.budget.Count(c) > tt.budget.Max {
+					t.Errorf("chunk %q is invalid or over budget", c)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildChunks(t *testing.T) {
+	segments := []Segment{
+		{ID: 1, Text: "One two three."},
+		{ID: 2, Text: "Four five six."},
+		{ID: 3, Text: "A much longer segment. It has to be split in two."},
+	}
+	budget := TextBudget{Max: 30, Count: utf8.RuneCountInString}
+	chunks := buildChunks(segments, budget, ".")
+
+	var ids []int
+	var texts []string
+	for _, chunk := range chunks {
+		size := 0
+		for _, p := range chunk {
+			size += budget.Count(p.Text)
+			ids = append(ids, p.ID)
+			texts = append(texts, p.Text)
+		}
+		if size > budget.Max {
+			t.Errorf("chunk %v is over budget", chunk)
+		}
+	}
+	if want := []int{1, 2, 3, 3}; !slices.Equal(ids, want) {
+		t.Errorf("piece IDs = %v, want %v", ids, want)
+	}
+	if got, want := strings.Join(texts, " "), "One two three. Four five six. A much longer segment. It has to be split in two."; got != want {
+		t.Errorf("pieces = %q", texts)
+	}
+}
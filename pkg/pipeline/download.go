@@ -0,0 +1,394 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// DownloadOptions selects which YouTube streams are downloaded
+type DownloadOptions struct {
+	MaxHeight int    // highest video resolution to pick, e.g. 720; 0 means no limit
+	Codec     string // preferred video codec prefix: avc1, vp9, av01; empty picks the best of any
+	Container string // output container (file extension): mp4 or mkv
+	AudioOnly bool   // skip video and download just the audio stream (saved as .m4a)
+	Retries   int    `json:"-"` // attempts after a failed request, with exponential backoff
+}
+
+// DefaultDownloadOptions picks the best H.264 stream saved as MP4
+var DefaultDownloadOptions = DownloadOptions{Codec: "avc1", Container: "mp4", Retries: 5}
+
+const (
+	downloadChunkSize  = 10 << 20 // bytes per range request
+	downloadMaxBackoff = 30 * time.Second
+)
+
+// downloadFileExt is the extension of the downloaded source file
+func (o DownloadOptions) downloadFileExt() string {
+	if o.AudioOnly {
+		return ".m4a"
+	}
+	return "." + o.Container
+}
+
+// DownloadYouTube saves a YouTube video to outputFile. The best adaptive video and audio
+// streams are downloaded separately and muxed with ffmpeg; when there is no suitable
+// adaptive video the best progressive (video+audio) stream is used instead.
+// Download progress is written to progress when it is not nil.
+func DownloadYouTube(ctx context.Context, client *youtube.Client, video *youtube.Video, outputFile string, opts DownloadOptions, progress io.Writer) error {
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	audio := selectAudioFormat(video.Formats, opts.AudioOnly || opts.Container == "mp4")
+	if opts.AudioOnly {
+		if audio == nil {
+			return fmt.Errorf("audio format မရှိ")
+		}
+		return downloadAndRemux(ctx, client, video, audio, outputFile, opts, progress)
+	}
+
+	videoFormat := selectVideoFormat(video.Formats, opts)
+	if videoFormat == nil || audio == nil {
+		// ပထမ progressive format ရွေးချယ်ခြင်း
+		progressive := selectProgressiveFormat(video.Formats, opts.MaxHeight)
+		if progressive == nil {
+			return fmt.Errorf("video format မရှိ")
+		}
+		fmt.Fprintf(progress, "🎞️ Format: %s %s (progressive)\n", progressive.QualityLabel, progressive.MimeType)
+		return downloadAndRemux(ctx, client, video, progressive, outputFile, opts, progress)
+	}
+
+	fmt.Fprintf(progress, "🎞️ Video: %s %s\n", videoFormat.QualityLabel, videoFormat.MimeType)
+	fmt.Fprintf(progress, "🎧 Audio: %s %s\n", audio.AudioQuality, audio.MimeType)
+
+	// Streams are kept until the mux succeeds so a rerun can resume them
+	videoPart := outputFile + ".video" + formatExt(videoFormat)
+	audioPart := outputFile + ".audio" + formatExt(audio)
+
+	if err := downloadFormat(ctx, client, video, videoFormat, videoPart, opts, progress); err != nil {
+		return err
+	}
+	if err := downloadFormat(ctx, client, video, audio, audioPart, opts, progress); err != nil {
+		return err
+	}
+	if err := muxStreams(ctx, outputFile, videoPart, audioPart); err != nil {
+		return err
+	}
+	os.Remove(videoPart)
+	os.Remove(audioPart)
+	return nil
+}
+
+// downloadAndRemux downloads one stream, remuxing it when its container does not match outputFile
+func downloadAndRemux(ctx context.Context, client *youtube.Client, video *youtube.Video, format *youtube.Format, outputFile string, opts DownloadOptions, progress io.Writer) error {
+	if strings.EqualFold(formatExt(format), filepath.Ext(outputFile)) {
+		return downloadFormat(ctx, client, video, format, outputFile, opts, progress)
+	}
+
+	stream := outputFile + ".stream" + formatExt(format)
+	if err := downloadFormat(ctx, client, video, format, stream, opts, progress); err != nil {
+		return err
+	}
+	if err := muxStreams(ctx, outputFile, stream); err != nil {
+		return err
+	}
+	os.Remove(stream)
+	return nil
+}
+
+// downloadFormat downloads one format into file. Data goes to file+".part" first, so an
+// interrupted download resumes with HTTP range requests; failed requests are retried
+// with exponential backoff. The part file is promoted to file only after its size
+// matches the format's content length and ffprobe can read it.
+func downloadFormat(ctx context.Context, client *youtube.Client, video *youtube.Video, format *youtube.Format, file string, opts DownloadOptions, progress io.Writer) error {
+	totalSize := format.ContentLength
+	if info, err := os.Stat(file); err == nil && (totalSize == 0 || info.Size() == totalSize) {
+		fmt.Fprintf(progress, "✅ Already downloaded: %s\n", file)
+		return nil
+	}
+	fmt.Fprintf(progress, "📦 Size: %.2f MB\n", float64(totalSize)/(1024*1024))
+
+	part := file + ".part"
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fetchToPart(ctx, client, video, format, part, progress); err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= opts.Retries {
+			return fmt.Errorf("download failed after %d attempts: %w", attempt+1, err)
+		}
+
+		delay := min(time.Second<<attempt, downloadMaxBackoff)
+		fmt.Fprintf(progress, "\n🔁 Retry %d/%d in %s: %v\n", attempt+1, opts.Retries, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Integrity checks before the file gets its final name
+	info, err := os.Stat(part)
+	if err != nil {
+		return err
+	}
+	if totalSize > 0 && info.Size() != totalSize {
+		os.Remove(part)
+		return fmt.Errorf("downloaded %d bytes, expected %d", info.Size(), totalSize)
+	}
+	if err := probeMedia(ctx, part); err != nil {
+		os.Remove(part)
+		return fmt.Errorf("downloaded file is not valid media: %w", err)
+	}
+	return os.Rename(part, file)
+}
+
+// fetchToPart appends the rest of the stream to part, starting at its current size
+func fetchToPart(ctx context.Context, client *youtube.Client, video *youtube.Video, format *youtube.Format, part string, progress io.Writer) error {
+	// Stream URLs expire, so every attempt asks for a fresh one
+	url, err := client.GetStreamURLContext(ctx, video, format)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	offset, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	totalSize := format.ContentLength
+	if totalSize > 0 && offset > totalSize {
+		// Bigger than the stream: start over
+		if err := out.Truncate(0); err != nil {
+			return err
+		}
+		offset, _ = out.Seek(0, io.SeekStart)
+	}
+	if offset > 0 {
+		fmt.Fprintf(progress, "⏯️ Resuming at %.2f MB\n", float64(offset)/(1024*1024))
+	}
+
+	for totalSize == 0 || offset < totalSize {
+		end := int64(-1) // open-ended when the size is unknown
+		if totalSize > 0 {
+			end = min(offset+downloadChunkSize, totalSize) - 1
+		}
+
+		var done bool
+		offset, done, err = fetchRange(ctx, client, url, offset, end, out, func(downloaded int64) {
+			printDownloadProgress(progress, downloaded, totalSize)
+		})
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+	fmt.Fprintln(progress) // New line after progress
+
+	return out.Close()
+}
+
+// fetchRange requests bytes offset..end (end < 0 = to the end of the stream) and
+// writes them to out. It returns the new file size; done reports that the server
+// sent the whole remaining stream.
+func fetchRange(ctx context.Context, client *youtube.Client, url string, offset, end int64, out *os.File, onProgress func(int64)) (int64, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return offset, false, err
+	}
+	if end < 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	} else {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, end))
+	}
+	req.Header.Set("Origin", "https://youtube.com")
+
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return offset, false, err
+	}
+	defer resp.Body.Close()
+
+	done := end < 0
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// Range ignored: the body is the whole stream, so start the file over
+		if err := out.Truncate(0); err != nil {
+			return offset, false, err
+		}
+		if offset, err = out.Seek(0, io.SeekStart); err != nil {
+			return offset, false, err
+		}
+		done = true
+	default:
+		return offset, false, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := out.Write(buf[:n]); err != nil {
+				return offset, false, err
+			}
+			offset += int64(n)
+			onProgress(offset)
+		}
+		if readErr == io.EOF {
+			return offset, done, nil
+		}
+		if readErr != nil {
+			return offset, false, readErr
+		}
+	}
+}
+
+func printDownloadProgress(progress io.Writer, downloaded, totalSize int64) {
+	if totalSize > 0 {
+		percent := float64(downloaded) / float64(totalSize) * 100
+		fmt.Fprintf(progress, "\r⬇️  Downloading: %.1f%% (%.2f MB / %.2f MB)", percent, float64(downloaded)/(1024*1024), float64(totalSize)/(1024*1024))
+	} else {
+		fmt.Fprintf(progress, "\r⬇️  Downloaded: %.2f MB", float64(downloaded)/(1024*1024))
+	}
+}
+
+// probeMedia checks that ffprobe recognizes file as audio or video
+func probeMedia(ctx context.Context, file string) error {
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error",
+		"-show_entries", "stream=codec_type",
+		"-of", "csv=p=0",
+		file,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffprobe error: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	if !strings.Contains(string(out), "audio") && !strings.Contains(string(out), "video") {
+		return fmt.Errorf("ffprobe found no audio or video stream")
+	}
+	return nil
+}
+
+// muxStreams copies the first video and first audio stream of the inputs into outputFile without re-encoding
+func muxStreams(ctx context.Context, outputFile string, inputs ...string) error {
+	args := []string{"-y", "-v", "error"}
+	for _, input := range inputs {
+		args = append(args, "-i", input)
+	}
+	args = append(args, "-map", "0:v:0?")
+	args = append(args, "-map", fmt.Sprintf("%d:a:0?", len(inputs)-1))
+	args = append(args, "-c", "copy", outputFile)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg mux error: %w", err)
+	}
+	return nil
+}
+
+// selectVideoFormat picks the best video-only (adaptive) stream within the options
+func selectVideoFormat(formats youtube.FormatList, opts DownloadOptions) *youtube.Format {
+	candidates := formats.Select(func(f youtube.Format) bool {
+		return strings.HasPrefix(f.MimeType, "video/") && f.AudioChannels == 0 &&
+			(opts.MaxHeight == 0 || f.Height <= opts.MaxHeight)
+	})
+	return best(candidates, func(a, b youtube.Format) int {
+		return cmp.Or(
+			cmp.Compare(codecMatch(a, opts.Codec), codecMatch(b, opts.Codec)),
+			cmp.Compare(a.Height, b.Height),
+			cmp.Compare(a.FPS, b.FPS),
+			cmp.Compare(a.Bitrate, b.Bitrate),
+		)
+	})
+}
+
+// selectAudioFormat picks the best audio-only stream, preferring AAC (m4a) when preferMP4 is set
+func selectAudioFormat(formats youtube.FormatList, preferMP4 bool) *youtube.Format {
+	candidates := formats.Select(func(f youtube.Format) bool {
+		return strings.HasPrefix(f.MimeType, "audio/")
+	})
+	return best(candidates, func(a, b youtube.Format) int {
+		if preferMP4 {
+			if c := cmp.Compare(codecMatch(a, "mp4a"), codecMatch(b, "mp4a")); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.Bitrate, b.Bitrate)
+	})
+}
+
+// selectProgressiveFormat picks the highest resolution stream that has both video and audio,
+// or the lowest one when none fits under maxHeight
+func selectProgressiveFormat(formats youtube.FormatList, maxHeight int) *youtube.Format {
+	progressive := formats.WithAudioChannels().Type("video/")
+	byQuality := func(a, b youtube.Format) int {
+		return cmp.Or(cmp.Compare(a.Height, b.Height), cmp.Compare(a.Bitrate, b.Bitrate))
+	}
+
+	fitting := progressive.Select(func(f youtube.Format) bool {
+		return maxHeight == 0 || f.Height <= maxHeight
+	})
+	if len(fitting) == 0 && len(progressive) > 0 {
+		f := slices.MinFunc(progressive, byQuality)
+		return &f
+	}
+	return best(fitting, byQuality)
+}
+
+// best returns the maximum format by compare, or nil for an empty list
+func best(formats youtube.FormatList, compare func(a, b youtube.Format) int) *youtube.Format {
+	if len(formats) == 0 {
+		return nil
+	}
+	f := slices.MaxFunc(formats, compare)
+	return &f
+}
+
+func codecMatch(f youtube.Format, codec string) int {
+	if codec != "" && strings.Contains(f.MimeType, `codecs="`+codec) {
+		return 1
+	}
+	return 0
+}
+
+// formatExt maps a format's MIME type to a file extension (video/mp4 → .mp4, audio/mp4 → .m4a)
+func formatExt(f *youtube.Format) string {
+	container, _, _ := strings.Cut(f.MimeType, ";")
+	switch container {
+	case "audio/mp4":
+		return ".m4a"
+	case "video/mp4":
+		return ".mp4"
+	case "video/3gpp":
+		return ".3gp"
+	default:
+		return ".webm"
+	}
+}
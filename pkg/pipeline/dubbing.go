@@ -0,0 +1,186 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dubSampleRate = 24000 // common rate all clips are resampled to
+	dubMaxTempo   = 2.0   // fastest allowed speed-up before the clip is cut at its slot end
+	dubMinTempo   = 0.85  // slowest allowed slow-down; the rest of the slot is padded with silence
+)
+
+// BuildTimedDub speaks each segment and lines the clips up with the source timing.
+// Every segment owns the slot from its start to the next segment's start; a clip that
+// does not fit is sped up (or slowed down) within limits, then padded or trimmed to the slot.
+// Untranslated segments, still in the source language, are left silent.
+// Progress is written to progress when it is not nil.
+func BuildTimedDub(ctx context.Context, segments []Segment, synthesizer Synthesizer, voice Voice, outputAudio string, progress io.Writer) error {
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outputAudio), "dub_segments_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	var parts []string
+
+	// Leading silence before the first segment
+	if len(segments) > 0 && segments[0].Start > 0 {
+		lead := filepath.Join(workDir, "lead.wav")
+		if err := writeSilence(ctx, lead, segments[0].Start); err != nil {
+			return err
+		}
+		parts = append(parts, lead)
+	}
+
+	slots := dubSlots(segments)
+	for i, seg := range segments {
+		slot := slots[i]
+		if slot <= 0 {
+			continue
+		}
+
+		fmt.Fprintf(progress, "\r  Speaking segment %d/%d...", i+1, len(segments))
+		fitted := filepath.Join(workDir, fmt.Sprintf("seg_%05d.wav", i))
+
+		if strings.TrimSpace(seg.Text) == "" || seg.Untranslated {
+			if err := writeSilence(ctx, fitted, slot); err != nil {
+				return err
+			}
+			parts = append(parts, fitted)
+			continue
+		}
+
+		clip := filepath.Join(workDir, fmt.Sprintf("seg_%05d.clip", i))
+		if err := synthesizer.Synthesize(ctx, seg.Text, voice, clip); err != nil {
+			return fmt.Errorf("segment %d: %w", i+1, err)
+		}
+		if err := fitClipToSlot(ctx, clip, fitted, slot); err != nil {
+			return fmt.Errorf("segment %d: %w", i+1, err)
+		}
+		parts = append(parts, fitted)
+	}
+	fmt.Fprintln(progress) // New line after progress
+
+	return concatAudio(ctx, parts, filepath.Join(workDir, "parts.txt"), outputAudio)
+}
+
+// dubSlots gives each segment the time from its start to the next segment's start (the
+// last one keeps its own length). Overlapping segments are cut at the next start so the
+// dub stays on the source timeline; a segment with no time left gets 0 and is not spoken.
+func dubSlots(segments []Segment) []time.Duration {
+	slots := make([]time.Duration, len(segments))
+	for i, seg := range segments {
+		slots[i] = seg.End - seg.Start
+		if i+1 < len(segments) {
+			slots[i] = max(0, segments[i+1].Start-seg.Start)
+		}
+	}
+	return slots
+}
+
+// fitClipToSlot changes a clip's tempo to fit the slot and pads or trims it to exactly slot length
+func fitClipToSlot(ctx context.Context, clip, outputFile string, slot time.Duration) error {
+	clipDuration, err := probeDuration(ctx, clip)
+	if err != nil {
+		return err
+	}
+
+	tempo := clipDuration.Seconds() / slot.Seconds()
+	tempo = min(max(tempo, dubMinTempo), dubMaxTempo)
+
+	filter := fmt.Sprintf("atempo=%.4f,apad,atrim=0:%.3f", tempo, slot.Seconds())
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-v", "error",
+		"-i", clip,
+		"-af", filter,
+		"-ar", strconv.Itoa(dubSampleRate),
+		"-ac", "1",
+		"-c:a", "pcm_s16le",
+		outputFile,
+	)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg error: %w", err)
+	}
+	return nil
+}
+
+// writeSilence writes a silent wav file of the given length
+func writeSilence(ctx context.Context, outputFile string, length time.Duration) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-v", "error",
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("anullsrc=r=%d:cl=mono", dubSampleRate),
+		"-t", fmt.Sprintf("%.3f", length.Seconds()),
+		"-c:a", "pcm_s16le",
+		outputFile,
+	)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg error: %w", err)
+	}
+	return nil
+}
+
+// concatAudio joins same-format wav parts into one audio file (ffmpeg concat demuxer)
+func concatAudio(ctx context.Context, parts []string, listFile, outputAudio string) error {
+	if len(parts) == 0 {
+		return fmt.Errorf("no audio to concatenate")
+	}
+
+	var list strings.Builder
+	for _, part := range parts {
+		abs, err := filepath.Abs(part)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(abs, "'", `'\''`))
+	}
+	if err := os.WriteFile(listFile, []byte(list.String()), 0644); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-v", "error",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", listFile,
+		outputAudio,
+	)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg error: %w", err)
+	}
+	return nil
+}
+
+// probeDuration returns a media file's duration (ffprobe)
+func probeDuration(ctx context.Context, file string) (time.Duration, error) {
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "csv=p=0",
+		file,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe error: %w", err)
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe duration %q: %w", strings.TrimSpace(string(out)), err)
+	}
+	return secondsToDuration(seconds), nil
+}
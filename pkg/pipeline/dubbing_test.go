@@ -0,0 +1,32 @@
+package pipeline
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDubSlots(t *testing.T) {
+	s := func(start, end int) Segment {
+		return Segment{Start: time.Duration(start) * time.Second, End: time.Duration(end) * time.Second}
+	}
+	tests := []struct {
+		name     string
+		segments []Segment
+		want     []int
+	}{
+		{"gaps", []Segment{s(0, 2), s(3, 5), s(7, 8)}, []int{3, 4, 1}},
+		{"overlap", []Segment{s(0, 4), s(3, 6)}, []int{3, 3}},
+		{"same start", []Segment{s(1, 2), s(1, 3), s(4, 5)}, []int{0, 3, 1}},
+		{"out of order", []Segment{s(5, 6), s(4, 7)}, []int{0, 3}},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, slot := range dubSlots(tt.segments) {
+			got = append(got, int(slot/time.Second))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: dubSlots = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
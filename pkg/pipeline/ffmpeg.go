@@ -0,0 +1,200 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/banyar-sithu/video/pkg/subtitle"
+)
+
+// StreamTags label an output stream for players' track menus
+type StreamTags struct {
+	Language string // ISO 639-2 code, e.g. "mya"
+	Title    string
+}
+
+// SubtitleStream is a subtitle file muxed as a soft (selectable) subtitle track
+type SubtitleStream struct {
+	File string
+	StreamTags
+}
+
+// MergeOptions lists the streams MergeAudioWithVideo writes besides the picture
+type MergeOptions struct {
+	Dub StreamTags // the new audio, the default track
+
+	// KeepOriginal keeps the video's own audio, if it has any, as a second track
+	KeepOriginal bool
+	Original     StreamTags
+
+	Subtitles []SubtitleStream // none is shown by default
+}
+
+// MergeAudioWithVideo puts audioFile on the video as its default audio track, next to
+// the original audio and subtitle tracks as opts asks (ffmpeg အသုံးပြု). MP4 output
+// stores subtitles as mov_text, other containers (MKV) copy them.
+func MergeAudioWithVideo(ctx context.Context, videoFile, audioFile, outputFile string, opts MergeOptions) error {
+	duration, err := probeDuration(ctx, videoFile)
+	if err != nil {
+		return err
+	}
+	if opts.KeepOriginal {
+		if opts.KeepOriginal, err = hasAudio(ctx, videoFile); err != nil {
+			return err
+		}
+	}
+
+	// ffmpeg -i video.mp4 -i burmese_audio.mp3 -i english.srt -map 0:v:0 -map 1:a:0 -map 0:a:0 -map 2:s:0
+	//   -c:v copy -c:a:1 copy -c:s mov_text -filter:a:0 apad -t <video duration> output.mp4
+	// apad pads the dub with silence and -t cuts everything at the video's length
+	// (-shortest would also stop at the last subtitle)
+	args := []string{"-y", "-i", videoFile, "-i", audioFile}
+	for _, s := range opts.Subtitles {
+		args = append(args, "-i", s.File)
+	}
+	args = append(args, "-map", "0:v:0", "-map", "1:a:0")
+	if opts.KeepOriginal {
+		args = append(args, "-map", "0:a:0")
+	}
+	for i := range opts.Subtitles {
+		args = append(args, "-map", fmt.Sprintf("%d:s:0", i+2))
+	}
+
+	args = append(args, "-c:v", "copy", "-filter:a:0", "apad", "-t", strconv.FormatFloat(duration.Seconds(), 'f', 3, 64))
+	args = append(args, streamArgs("a:0", opts.Dub, "default")...)
+	if opts.KeepOriginal {
+		args = append(args, "-c:a:1", "copy")
+		args = append(args, streamArgs("a:1", opts.Original, "0")...)
+	}
+	if len(opts.Subtitles) > 0 {
+		codec := "copy"
+		switch strings.ToLower(filepath.Ext(outputFile)) {
+		case ".mp4", ".m4v", ".mov":
+			codec = "mov_text"
+		}
+		args = append(args, "-c:s", codec)
+	}
+	for i, s := range opts.Subtitles {
+		args = append(args, streamArgs(fmt.Sprintf("s:%d", i), s.StreamTags, "0")...)
+	}
+	args = append(args, outputFile)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg error: %w", err)
+	}
+	return nil
+}
+
+// streamArgs tags an output stream and sets its disposition ("default" or "0")
+func streamArgs(stream string, tags StreamTags, disposition string) []string {
+	args := []string{"-disposition:" + stream, disposition}
+	if tags.Language != "" {
+		args = append(args, "-metadata:s:"+stream, "language="+tags.Language)
+	}
+	if tags.Title != "" {
+		args = append(args, "-metadata:s:"+stream, "title="+tags.Title)
+	}
+	return args
+}
+
+// hasAudio reports whether file has an audio stream (ffprobe)
+func hasAudio(ctx context.Context, file string) (bool, error) {
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error",
+		"-select_streams", "a",
+		"-show_entries", "stream=index",
+		"-of", "csv=p=0",
+		file,
+	).Output()
+	if err != nil {
+		return false, fmt.Errorf("ffprobe error: %w", err)
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// SubtitleStyle controls how burned-in subtitles are rendered
+type SubtitleStyle struct {
+	FontFile string // optional font file; its directory is passed to libass as fontsdir
+	FontName string // family name libass should pick (must support Myanmar script)
+	FontSize int
+	Outline  int
+	MarginV  int // bottom margin
+}
+
+// DefaultSubtitleStyle renders Myanmar script with Noto Sans Myanmar
+var DefaultSubtitleStyle = SubtitleStyle{
+	FontName: "Noto Sans Myanmar",
+	FontSize: 24,
+	Outline:  2,
+	MarginV:  30,
+}
+
+// DefaultSubtitleLayout fits burned subtitles to the 384 pixel wide frame libass draws
+// SRT files on, less its 10 pixel side margins, so libass never wraps them again
+var DefaultSubtitleLayout = subtitle.Layout{
+	MaxLineWidth: 364,
+	Unit:         subtitle.Pixels,
+	MaxLines:     2,
+	MaxCPS:       subtitle.DefaultLayout.MaxCPS,
+	MinDuration:  subtitle.DefaultLayout.MinDuration,
+	MinGap:       subtitle.DefaultLayout.MinGap,
+}
+
+// forceStyle builds the ASS force_style override for the subtitles filter
+func (o SubtitleStyle) forceStyle() string {
+	return fmt.Sprintf("FontName=%s,FontSize=%d,Outline=%d,MarginV=%d,BorderStyle=1",
+		o.FontName, o.FontSize, o.Outline, o.MarginV)
+}
+
+// BurnSubtitles renders subtitles into the video picture (ffmpeg subtitles filter, libass).
+// style overrides the look of SRT files; ASS files keep their own styles.
+func BurnSubtitles(ctx context.Context, videoFile, subtitleFile, outputFile string, style SubtitleStyle) error {
+	filter := "subtitles=filename=" + escapeFilterValue(subtitleFile)
+	if style.FontFile != "" {
+		if _, err := os.Stat(style.FontFile); err != nil {
+			return fmt.Errorf("subtitle font: %w", err)
+		}
+		filter += ":fontsdir=" + escapeFilterValue(filepath.Dir(style.FontFile))
+	}
+	if !strings.EqualFold(filepath.Ext(subtitleFile), ".ass") {
+		filter += ":force_style=" + escapeFilterValue(style.forceStyle())
+	}
+
+	// ffmpeg -i input.mp4 -map 0:v:0 -map 0:a? -map 0:s? -vf subtitles=... -c:a copy -c:s copy output.mp4
+	// Every audio and soft subtitle track of the dubbed video carries over, with its tags
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y",
+		"-i", videoFile,
+		"-map", "0:v:0",
+		"-map", "0:a?",
+		"-map", "0:s?",
+		"-vf", filter,
+		"-c:v", "libx264",
+		"-c:a", "copy",
+		"-c:s", "copy",
+		outputFile,
+	)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg error: %w", err)
+	}
+	return nil
+}
+
+// escapeFilterValue escapes a value for a filter option inside an ffmpeg filtergraph.
+// Both levels apply: option values (\ ' :) and the filtergraph itself (\ ' [ ] , ;).
+func escapeFilterValue(value string) string {
+	optionLevel := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`)
+	graphLevel := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`)
+	return graphLevel.Replace(optionLevel.Replace(value))
+}
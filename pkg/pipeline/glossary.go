@@ -0,0 +1,319 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GlossaryTerm maps a source term to the target term the translation must use.
+// A term whose Target equals Source must not be translated.
+type GlossaryTerm struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Glossary is a list of required translations and do-not-translate terms
+type Glossary struct {
+	Terms []GlossaryTerm `json:"terms"`
+
+	once     sync.Once
+	patterns []termPattern // Terms longest first, compiled on first use
+}
+
+type termPattern struct {
+	term GlossaryTerm
+	re   *regexp.Regexp
+}
+
+// LoadGlossary reads a CSV file of "source term,target term" rows. An empty target
+// means the term must stay untranslated. Lines starting with # are comments, and a
+// first row of "source,target" is treated as a header.
+func LoadGlossary(file string) (*Glossary, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	g := &Glossary{}
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("glossary %s: %w", file, err)
+		}
+		if line == 1 && len(record) >= 2 && strings.EqualFold(record[0], "source") && strings.EqualFold(record[1], "target") {
+			continue
+		}
+
+		source := strings.TrimSpace(record[0])
+		target := ""
+		if len(record) > 1 {
+			target = strings.TrimSpace(record[1])
+		}
+		if source == "" {
+			continue
+		}
+		g.Add(source, target)
+	}
+	return g, nil
+}
+
+// LoadDoNotTranslate adds one term per line of file (# comments allowed) as
+// terms that must stay untranslated
+func (g *Glossary) LoadDoNotTranslate(file string) error {
+	terms, err := ReadSourceList(file)
+	if err != nil {
+		return fmt.Errorf("do-not-translate list: %w", err)
+	}
+	for _, t := range terms {
+		g.Add(t, "")
+	}
+	return nil
+}
+
+// Add adds a term; an empty target keeps the source term
+func (g *Glossary) Add(source, target string) {
+	if target == "" {
+		target = source
+	}
+	g.Terms = append(g.Terms, GlossaryTerm{Source: source, Target: target})
+}
+
+// termMatch is one occurrence of a glossary term in a text
+type termMatch struct {
+	start, end int
+	term       GlossaryTerm
+}
+
+// find returns non-overlapping occurrences of glossary terms, longest terms first.
+// Matching ignores case; terms starting or ending with a Latin letter or digit must
+// stand as whole words ("API" does not match "rapid").
+func (g *Glossary) find(text string) []termMatch {
+	g.once.Do(func() {
+		terms := append([]GlossaryTerm(nil), g.Terms...)
+		sort.SliceStable(terms, func(i, j int) bool {
+			return utf8.RuneCountInString(terms[i].Source) > utf8.RuneCountInString(terms[j].Source)
+		})
+		for _, t := range terms {
+			g.patterns = append(g.patterns, termPattern{t, regexp.MustCompile("(?i)" + regexp.QuoteMeta(t.Source))})
+		}
+	})
+
+	taken := make([]bool, len(text))
+	var matches []termMatch
+	for _, p := range g.patterns {
+		for _, loc := range p.re.FindAllStringIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if !wordBoundary(text, start, end) || overlaps(taken, start, end) {
+				continue
+			}
+			for k := start; k < end; k++ {
+				taken[k] = true
+			}
+			matches = append(matches, termMatch{start, end, p.term})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	return matches
+}
+
+func overlaps(taken []bool, start, end int) bool {
+	for k := start; k < end; k++ {
+		if taken[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// wordBoundary checks the characters around text[start:end] for Latin word terms
+func wordBoundary(text string, start, end int) bool {
+	first, _ := utf8.DecodeRuneInString(text[start:])
+	last, _ := utf8.DecodeLastRuneInString(text[:end])
+	if isLatinWordRune(first) && start > 0 {
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isLatinWordRune(before) {
+			return false
+		}
+	}
+	if isLatinWordRune(last) && end < len(text) {
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isLatinWordRune(after) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLatinWordRune(r rune) bool {
+	return unicode.IsDigit(r) || unicode.Is(unicode.Latin, r)
+}
+
+// placeholderRe matches placeholders even when the translator adds spaces inside them
+var placeholderRe = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+
+// protect replaces glossary terms with numbered placeholders like ⟦1⟧
+func (g *Glossary) protect(text string) (string, []GlossaryTerm) {
+	matches := g.find(text)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	terms := make([]GlossaryTerm, len(matches))
+	last := 0
+	for i, m := range matches {
+		b.WriteString(text[last:m.start])
+		fmt.Fprintf(&b, "⟦%d⟧", i+1)
+		terms[i] = m.term
+		last = m.end
+	}
+	b.WriteString(text[last:])
+	return b.String(), terms
+}
+
+// restore puts the target terms where protect left placeholders
+func restore(text string, terms []GlossaryTerm) string {
+	return placeholderRe.ReplaceAllStringFunc(text, func(p string) string {
+		n, _ := strconv.Atoi(placeholderRe.FindStringSubmatch(p)[1])
+		if n < 1 || n > len(terms) {
+			return "" // invented by the translator
+		}
+		return terms[n-1].Target
+	})
+}
+
+// GlossaryTranslator enforces a glossary around another Translator: terms are replaced
+// by placeholders before translation and by their required target terms afterwards
+type GlossaryTranslator struct {
+	Translator Translator
+	Glossary   *Glossary
+}
+
+func (t *GlossaryTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	protected := make([]string, len(texts))
+	terms := make([][]GlossaryTerm, len(texts))
+	for i, text := range texts {
+		protected[i], terms[i] = t.Glossary.protect(text)
+	}
+
+	results, err := t.Translator.Translate(ctx, protected)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(texts) {
+		return nil, countError(len(results), len(texts))
+	}
+	for i := range results {
+		results[i] = restore(results[i], terms[i])
+	}
+	return results, nil
+}
+
+// Budget is the wrapped translator's budget
+func (t *GlossaryTranslator) Budget() TextBudget {
+	return budgetOf(t.Translator)
+}
+
+// ContextSize is the wrapped SegmentTranslator's, or 0 if it is not one
+func (t *GlossaryTranslator) ContextSize() int {
+	if st, ok := t.Translator.(SegmentTranslator); ok {
+		return st.ContextSize()
+	}
+	return 0
+}
+
+// TranslateWindow protects glossary terms in the segments to translate; context
+// segments are passed as they are
+func (t *GlossaryTranslator) TranslateWindow(ctx context.Context, window SegmentWindow) (map[int]string, error) {
+	st, ok := t.Translator.(SegmentTranslator)
+	if !ok {
+		return nil, errNoWindows(t.Translator)
+	}
+	protected := make([]Segment, len(window.Segments))
+	terms := make(map[int][]GlossaryTerm, len(window.Segments))
+	for i, s := range window.Segments {
+		s.Text, terms[s.ID] = t.Glossary.protect(s.Text)
+		protected[i] = s
+	}
+	window.Segments = protected
+
+	results, err := st.TranslateWindow(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	for id, text := range results {
+		results[id] = restore(text, terms[id])
+	}
+	return results, nil
+}
+
+// GlossaryViolation is a translated segment that lacks a required target term
+type GlossaryViolation struct {
+	SegmentID   int
+	Term        GlossaryTerm
+	Source      string
+	Translation string
+}
+
+// CheckGlossary lists translated segments whose source contains a glossary term
+// but whose translation does not contain the required target term
+func CheckGlossary(g *Glossary, source, translated []Segment) []GlossaryViolation {
+	byID := make(map[int]Segment, len(translated))
+	for _, s := range translated {
+		byID[s.ID] = s
+	}
+
+	var violations []GlossaryViolation
+	for _, s := range source {
+		t, ok := byID[s.ID]
+		if !ok || t.Untranslated {
+			continue
+		}
+		seen := map[GlossaryTerm]bool{}
+		for _, m := range g.find(s.Text) {
+			if seen[m.term] {
+				continue
+			}
+			seen[m.term] = true
+			if !strings.Contains(strings.ToLower(t.Text), strings.ToLower(m.term.Target)) {
+				violations = append(violations, GlossaryViolation{SegmentID: s.ID, Term: m.term, Source: s.Text, Translation: t.Text})
+			}
+		}
+	}
+	return violations
+}
+
+// WriteGlossaryReport saves violations as CSV, one row per missing term
+func WriteGlossaryReport(file string, violations []GlossaryViolation) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"segment", "source_term", "required_term", "source", "translation"})
+	for _, v := range violations {
+		w.Write([]string{strconv.Itoa(v.SegmentID), v.Term.Source, v.Term.Target, v.Source, v.Translation})
+	}
+	w.Flush()
+	return errors.Join(w.Error(), f.Close())
+}
@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Language describes what each stage needs to know about a source or target language
+type Language struct {
+	Code    string // registry key; also matched against YouTube caption tracks
+	Name    string // English name; its lower case is the output file suffix ("_burmese.srt")
+	Whisper string // Whisper --language value; empty if Whisper cannot transcribe it
+	Google  string // Google Translate language code; empty if Google cannot translate it
+	Libre   string // LibreTranslate language code; empty if LibreTranslate cannot
+	ISO639  string // ISO 639-2 code tagging audio and subtitle tracks in the output video
+
+	FemaleVoice string // default Edge TTS voices; empty if Edge has none
+	MaleVoice   string
+
+	FontName    string // subtitle font family with glyphs for the script
+	SentenceEnd string // punctuation that ends a sentence
+
+	// Normalize cleans up text in the language before TTS and subtitles, e.g. converting
+	// Zawgyi to Unicode for Burmese; nil leaves text as it is
+	Normalize func(string) string
+}
+
+// AutoLanguage lets Whisper detect the spoken language; it is only valid as a source
+var AutoLanguage = Language{Code: "auto", Name: "Source", Google: "auto", Libre: "auto", ISO639: "und", FontName: "Noto Sans"}
+
+// Languages is the language registry, keyed by Code
+var Languages = map[string]Language{
+	"en": {
+		Code: "en", Name: "English", Whisper: "en", Google: "en", Libre: "en", ISO639: "eng",
+		FemaleVoice: "en-US-JennyNeural", MaleVoice: "en-US-GuyNeural",
+		FontName: "Noto Sans", SentenceEnd: ".!?",
+	},
+	"my": {
+		Code: "my", Name: "Burmese", Whisper: "my", Google: "my", ISO639: "mya",
+		FemaleVoice: "my-MM-NilarNeural", MaleVoice: "my-MM-ThihaNeural",
+		FontName: "Noto Sans Myanmar", SentenceEnd: "။",
+		Normalize: NormalizeBurmese,
+	},
+	"shn": {
+		// Shan and Karen use the Myanmar script; no Whisper model or Edge voice exists yet,
+		// and only LLM translators (and Google, for Shan) translate them
+		Code: "shn", Name: "Shan", Google: "shn", ISO639: "shn",
+		FontName: "Noto Sans Myanmar", SentenceEnd: "။",
+	},
+	"ksw": {
+		Code: "ksw", Name: "Karen", ISO639: "ksw",
+		FontName: "Noto Sans Myanmar", SentenceEnd: "။",
+	},
+	"th": {
+		// Thai has no sentence punctuation; a space ends a sentence
+		Code: "th", Name: "Thai", Whisper: "th", Google: "th", Libre: "th", ISO639: "tha",
+		FemaleVoice: "th-TH-PremwadeeNeural", MaleVoice: "th-TH-NiwatNeural",
+		FontName: "Noto Sans Thai", SentenceEnd: " ",
+	},
+	"zh": {
+		Code: "zh", Name: "Chinese", Whisper: "zh", Google: "zh-CN", Libre: "zh", ISO639: "zho",
+		FemaleVoice: "zh-CN-XiaoxiaoNeural", MaleVoice: "zh-CN-YunxiNeural",
+		FontName: "Noto Sans CJK SC", SentenceEnd: "。！？",
+	},
+}
+
+// LookupLanguage finds a language by code or English name ("my", "burmese").
+// "auto" is only accepted when allowAuto is set.
+func LookupLanguage(name string, allowAuto bool) (Language, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == AutoLanguage.Code && allowAuto {
+		return AutoLanguage, nil
+	}
+	if lang, ok := Languages[name]; ok {
+		return lang, nil
+	}
+	for _, lang := range Languages {
+		if strings.ToLower(lang.Name) == name {
+			return lang, nil
+		}
+	}
+	return Language{}, fmt.Errorf("unknown language %q (known: %s)", name, strings.Join(LanguageCodes(), ", "))
+}
+
+// LanguageCodes lists the registry's codes in order
+func LanguageCodes() []string {
+	codes := make([]string, 0, len(Languages))
+	for code := range Languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Voice returns the default Edge TTS voice, preferring the female one when female is set
+func (l Language) Voice(female bool) string {
+	if female && l.FemaleVoice != "" || l.MaleVoice == "" {
+		return l.FemaleVoice
+	}
+	return l.MaleVoice
+}
+
+// NormalizeText applies Normalize if the language has one
+func (l Language) NormalizeText(text string) string {
+	if l.Normalize == nil {
+		return text
+	}
+	return l.Normalize(text)
+}
+
+// fileSuffix is the language part of output file names, e.g. "burmese"
+func (l Language) fileSuffix() string {
+	return strings.ToLower(l.Name)
+}
@@ -0,0 +1,173 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ManifestFile is the name of the per-video manifest inside the output directory
+const ManifestFile = "manifest.json"
+
+// Stage status values recorded in the manifest
+const (
+	StatusDone    = "done"
+	StatusPartial = "partial" // outputs written but incomplete; the stage runs again
+	StatusFailed  = "failed"
+)
+
+// Manifest records what each stage consumed and produced, so reruns can skip
+// stages whose inputs have not changed
+type Manifest struct {
+	Source   string                 `json:"source"`
+	VideoKey string                 `json:"video_key,omitempty"` // YouTube video ID or absolute path of a local file
+	Stages   map[Stage]*StageRecord `json:"stages"`
+}
+
+// StageRecord is one stage's entry in the manifest
+type StageRecord struct {
+	Status     string            `json:"status"`
+	Params     map[string]string `json:"params,omitempty"`
+	Inputs     []FileRecord      `json:"inputs,omitempty"`
+	Outputs    []FileRecord      `json:"outputs,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+}
+
+// FileRecord identifies a file's content by SHA-256
+type FileRecord struct {
+	Path    string    `json:"path"`
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// loadManifest reads a manifest, returning an empty one when the file does not exist
+func loadManifest(path string) (*Manifest, error) {
+	m := &Manifest{Stages: map[Stage]*StageRecord{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	if m.Stages == nil {
+		m.Stages = map[Stage]*StageRecord{}
+	}
+	return m, nil
+}
+
+func (m *Manifest) save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// knownFile returns the last recorded state of path, if any stage recorded it
+func (m *Manifest) knownFile(path string) *FileRecord {
+	for _, rec := range m.Stages {
+		for _, files := range [][]FileRecord{rec.Outputs, rec.Inputs} {
+			for i := range files {
+				if files[i].Path == path {
+					return &files[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// recordFile hashes a file. An earlier record with the same size and
+// modification time is trusted, so large videos are not re-hashed on every run.
+func (m *Manifest) recordFile(path string) (FileRecord, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileRecord{}, err
+	}
+	if known := m.knownFile(path); known != nil && known.Size == info.Size() && known.ModTime.Equal(info.ModTime()) {
+		return *known, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return FileRecord{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return FileRecord{}, err
+	}
+	return FileRecord{Path: path, SHA256: hex.EncodeToString(h.Sum(nil)), Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (m *Manifest) recordFiles(paths []string) ([]FileRecord, error) {
+	records := make([]FileRecord, 0, len(paths))
+	for _, path := range paths {
+		rec, err := m.recordFile(path)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// upToDate reports whether a finished stage can be skipped: same parameters, every
+// input unchanged, and every output still present. Outputs may have been edited by
+// hand (e.g. a reviewed SRT); later stages notice that through their own inputs.
+func (m *Manifest) upToDate(stage Stage, params map[string]string, inputs, outputs []string) bool {
+	rec := m.Stages[stage]
+	if rec == nil || rec.Status != StatusDone || !equalParams(rec.Params, params) || len(rec.Inputs) != len(inputs) {
+		return false
+	}
+
+	for i, path := range inputs {
+		current, err := m.recordFile(path)
+		if err != nil || rec.Inputs[i].Path != path || current.SHA256 != rec.Inputs[i].SHA256 {
+			return false
+		}
+	}
+	return outputsExist(outputs)
+}
+
+func outputsExist(outputs []string) bool {
+	for _, path := range outputs {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func equalParams(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// describeBackend identifies a backend's type and configuration for stage params
+func describeBackend(backend any) string {
+	config, err := json.Marshal(backend)
+	if err != nil {
+		return fmt.Sprintf("%T", backend)
+	}
+	return fmt.Sprintf("%T%s", backend, config)
+}
@@ -0,0 +1,369 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryFile is the translation memory used when no other file is given
+const DefaultMemoryFile = "translation_memory.jsonl"
+
+// MemoryEntry is one remembered translation
+type MemoryEntry struct {
+	Backend    string    `json:"backend"` // see BackendName
+	SourceLang string    `json:"source_lang"`
+	TargetLang string    `json:"target_lang"`
+	Source     string    `json:"source"`
+	Target     string    `json:"target"`
+	Created    time.Time `json:"created"`
+	LastUsed   time.Time `json:"last_used"`
+	Hits       int       `json:"hits"`
+}
+
+func (e *MemoryEntry) key() string {
+	return memoryKey(e.Backend, e.SourceLang, e.TargetLang, e.Source)
+}
+
+// memoryKey identifies a translation by backend, language pair and normalized source text
+func memoryKey(backend, sourceLang, targetLang, source string) string {
+	return strings.Join([]string{backend, sourceLang, targetLang, normalizeSegment(source)}, "\x00")
+}
+
+// normalizeSegment ignores case and differences in whitespace
+func normalizeSegment(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// TranslationMemory is a file of remembered translations, one JSON entry per line.
+// New and updated entries are appended, so several processes (burmese and live) can
+// share the file; the last line for a key wins when it is loaded. Compact rewrites it.
+type TranslationMemory struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]*MemoryEntry
+	removed map[string]bool // pruned keys, so Compact does not bring them back
+	dirty   bool            // hits not yet written
+}
+
+// OpenTranslationMemory loads the memory file, which need not exist yet
+func OpenTranslationMemory(path string) (*TranslationMemory, error) {
+	m := &TranslationMemory{path: path, removed: map[string]bool{}}
+	entries, err := readMemoryFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m.entries = entries
+	return m, nil
+}
+
+// readMemoryFile loads entries by key; a missing file has none
+func readMemoryFile(path string) (map[string]*MemoryEntry, error) {
+	entries := map[string]*MemoryEntry{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e MemoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("translation memory %s line %d: %w", path, line, err)
+		}
+		entries[e.key()] = &e
+	}
+	return entries, scanner.Err()
+}
+
+// Path is the memory file
+func (m *TranslationMemory) Path() string {
+	return m.path
+}
+
+// Len is the number of entries
+func (m *TranslationMemory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// Lookup returns the remembered translation of source and records the hit
+func (m *TranslationMemory) Lookup(backend, sourceLang, targetLang, source string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[memoryKey(backend, sourceLang, targetLang, source)]
+	if !ok {
+		return "", false
+	}
+	e.Hits++
+	e.LastUsed = time.Now().UTC()
+	m.dirty = true
+	return e.Target, true
+}
+
+// Store remembers entries, replacing earlier translations of the same source, and
+// appends them to the file
+func (m *TranslationMemory) Store(entries ...MemoryEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	var lines []byte
+	for _, e := range entries {
+		if e.Target == "" {
+			continue
+		}
+		if e.Created.IsZero() {
+			e.Created = now
+		}
+		if e.LastUsed.IsZero() {
+			e.LastUsed = e.Created
+		}
+		m.entries[e.key()] = &e
+		delete(m.removed, e.key())
+
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, data...), '\n')
+	}
+	return m.appendLines(lines)
+}
+
+func (m *TranslationMemory) appendLines(lines []byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	if dir := filepath.Dir(m.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(lines); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Entries returns every entry, oldest first
+func (m *TranslationMemory) Entries() []MemoryEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]MemoryEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Created.Before(entries[j].Created) })
+	return entries
+}
+
+// Prune removes entries for which remove returns true and compacts the file.
+// It returns the number of removed entries.
+func (m *TranslationMemory) Prune(remove func(MemoryEntry) bool) (int, error) {
+	m.mu.Lock()
+	removed := 0
+	for k, e := range m.entries {
+		if remove(*e) {
+			delete(m.entries, k)
+			m.removed[k] = true
+			removed++
+		}
+	}
+	m.mu.Unlock()
+	return removed, m.Compact()
+}
+
+// Close saves hit counts and last-used times if any entry was used
+func (m *TranslationMemory) Close() error {
+	m.mu.Lock()
+	dirty := m.dirty
+	m.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+	return m.Compact()
+}
+
+// Compact rewrites the file with one line per entry, also saving hit counts.
+// Entries another process appended since the file was loaded are kept.
+func (m *TranslationMemory) Compact() error {
+	onDisk, err := readMemoryFile(m.path)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	for k, e := range onDisk {
+		if _, ok := m.entries[k]; !ok && !m.removed[k] {
+			m.entries[k] = e
+		}
+	}
+	m.dirty = false
+	m.mu.Unlock()
+
+	entries := m.Entries()
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), m.path)
+}
+
+// BackendName names a translator for memory keys: the engine, plus the model for LLMs
+func BackendName(t Translator) string {
+	switch t := t.(type) {
+	case *DeepTranslator:
+		return "google"
+	case *OpenAITranslator:
+		return "openai/" + t.Model
+	case *LibreTranslator:
+		return "libretranslate"
+	case *GlossaryTranslator:
+		return BackendName(t.Translator)
+	case *MemoryTranslator:
+		return BackendName(t.Translator)
+	case *ContextTranslator:
+		return "openai-context/" + t.Model
+	default:
+		return fmt.Sprintf("%T", t)
+	}
+}
+
+// MemoryTranslator answers from a TranslationMemory and only sends texts it has not
+// seen to the wrapped Translator, remembering their translations
+type MemoryTranslator struct {
+	Translator Translator
+	Memory     *TranslationMemory `json:"-"`
+	SourceLang string
+	TargetLang string
+}
+
+func (t *MemoryTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	backend := BackendName(t.Translator)
+	results := make([]string, len(texts))
+
+	var missing []string
+	var missingIdx []int
+	for i, text := range texts {
+		if cached, ok := t.Memory.Lookup(backend, t.SourceLang, t.TargetLang, text); ok {
+			results[i] = cached
+			continue
+		}
+		missing = append(missing, text)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	translated, err := t.Translator.Translate(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(translated) != len(missing) {
+		return nil, countError(len(translated), len(missing))
+	}
+	entries := make([]MemoryEntry, len(missing))
+	for j, i := range missingIdx {
+		results[i] = translated[j]
+		entries[j] = MemoryEntry{Backend: backend, SourceLang: t.SourceLang, TargetLang: t.TargetLang, Source: missing[j], Target: translated[j]}
+	}
+	if err := t.Memory.Store(entries...); err != nil {
+		return nil, fmt.Errorf("translation memory: %w", err)
+	}
+	return results, nil
+}
+
+// Budget is the wrapped translator's budget
+func (t *MemoryTranslator) Budget() TextBudget {
+	return budgetOf(t.Translator)
+}
+
+// ContextSize is the wrapped SegmentTranslator's, or 0 if it is not one
+func (t *MemoryTranslator) ContextSize() int {
+	if st, ok := t.Translator.(SegmentTranslator); ok {
+		return st.ContextSize()
+	}
+	return 0
+}
+
+// TranslateWindow answers segments from memory and sends the others, with the same
+// context, to the wrapped SegmentTranslator
+func (t *MemoryTranslator) TranslateWindow(ctx context.Context, window SegmentWindow) (map[int]string, error) {
+	st, ok := t.Translator.(SegmentTranslator)
+	if !ok {
+		return nil, errNoWindows(t.Translator)
+	}
+	backend := BackendName(t.Translator)
+	results := make(map[int]string, len(window.Segments))
+
+	var missing []Segment
+	for _, s := range window.Segments {
+		if cached, ok := t.Memory.Lookup(backend, t.SourceLang, t.TargetLang, s.Text); ok {
+			results[s.ID] = cached
+			continue
+		}
+		missing = append(missing, s)
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	window.Segments = missing
+	translated, err := st.TranslateWindow(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]MemoryEntry, len(missing))
+	for j, s := range missing {
+		results[s.ID] = translated[s.ID]
+		entries[j] = MemoryEntry{Backend: backend, SourceLang: t.SourceLang, TargetLang: t.TargetLang, Source: s.Text, Target: translated[s.ID]}
+	}
+	if err := t.Memory.Store(entries...); err != nil {
+		return nil, fmt.Errorf("translation memory: %w", err)
+	}
+	return results, nil
+}
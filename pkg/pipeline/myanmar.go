@@ -0,0 +1,336 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Burmese text still arrives in Zawgyi, the legacy font encoding that reuses Myanmar
+// code points in visual order. Edge TTS mispronounces it and subtitle fonts render it
+// garbled, so everything headed for TTS or subtitles goes through NormalizeBurmese.
+
+// NormalizeBurmese converts Zawgyi text to Unicode and puts diacritics in the standard
+// storage order. Text without Myanmar characters is returned unchanged.
+func NormalizeBurmese(text string) string {
+	if !hasMyanmar(text) {
+		return text
+	}
+	if IsZawgyi(text) {
+		text = ZawgyiToUnicode(text)
+	}
+	return NormalizeMyanmarOrder(text)
+}
+
+func hasMyanmar(text string) bool {
+	for _, r := range text {
+		if r >= 0x1000 && r <= 0x109F {
+			return true
+		}
+	}
+	return false
+}
+
+func isMyanmarConsonant(r rune) bool {
+	return r >= 0x1000 && r <= 0x102A || r == 0x103F || r == 0x104E
+}
+
+// IsZawgyi guesses the encoding from sequences that are only valid in one of them:
+// a vowel sign E or medial RA at the start of a syllable, an asat (Zawgyi 1039) that
+// does not stack a consonant, or Zawgyi's extra glyphs (1060-1097), against stacked
+// consonants, kinzi and medial HA in Unicode
+func IsZawgyi(text string) bool {
+	runes := []rune(text)
+	zawgyi, unicode := 0, 0
+	for i, r := range runes {
+		var prev, next rune
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case r >= 0x1060 && r <= 0x1097:
+			zawgyi += 2
+		case r == 0x1033, r == 0x1034, r == 0x105A:
+			zawgyi++
+		case r == 0x1039:
+			if isMyanmarConsonant(next) {
+				unicode++ // also a Zawgyi asat at the end of a syllable
+			} else {
+				zawgyi += 2
+			}
+		case r == 0x1031, r == 0x103B:
+			// Unicode keeps these after their consonant (and medials)
+			if !isMyanmarConsonant(prev) && !(prev >= 0x103B && prev <= 0x103E) {
+				zawgyi += 2
+			}
+		case r == 0x103E:
+			unicode++
+		case r == 0x103A && prev == 0x1004 && next == 0x1039:
+			unicode += 2
+		}
+	}
+	return zawgyi > unicode
+}
+
+// zawgyiMap maps Zawgyi code points to their Unicode sequences. Kinzi (1064, 108B-108D)
+// and the prefix vowels are handled by ZawgyiToUnicode.
+var zawgyiMap = map[rune]string{
+	0x1033: "ု", 0x1034: "ူ",
+	0x1039: "်", // asat
+	0x103A: "ျ", // medial YA
+	0x103B: "ြ", 0x107E: "ြ", 0x107F: "ြ", 0x1080: "ြ", 0x1081: "ြ",
+	0x1082: "ြ", 0x1083: "ြ", 0x1084: "ြ", // medial RA and its widths
+	0x103C: "ွ",              // medial WA
+	0x103D: "ှ", 0x1087: "ှ", // medial HA
+	0x107D: "ျ",
+	0x108A: "ွှ",
+	0x1088: "ှု", 0x1089: "ှူ",
+	0x105A: "ါ်",
+	0x1060: "္က", 0x1061: "္ခ", 0x1062: "္ဂ", 0x1063: "္ဃ",
+	0x1065: "္စ", 0x1066: "္ဆ", 0x1067: "္ဆ",
+	0x1068: "္ဇ", 0x1069: "္ဈ",
+	0x106A: "ဉ", 0x106B: "ည",
+	0x106C: "္ဋ", 0x106D: "္ဌ",
+	0x106E: "ဍ္ဍ", 0x106F: "ဍ္ဎ",
+	0x1070: "္ဏ",
+	0x1071: "္တ", 0x1072: "္တ", 0x1096: "္တွ",
+	0x1073: "္ထ", 0x1074: "္ထ",
+	0x1075: "္ဒ", 0x1076: "္ဓ", 0x1077: "္န",
+	0x1078: "္ပ", 0x1079: "္ဖ", 0x107A: "္ဗ",
+	0x107B: "္ဘ", 0x1093: "္ဘ",
+	0x107C: "္မ", 0x1085: "္လ",
+	0x1086: "ဿ",
+	0x108E: "ိံ",
+	0x108F: "န", 0x1090: "ရ",
+	0x1091: "ဏ္ဍ", 0x1092: "ဋ္ဌ", 0x1097: "ဋ္ဋ",
+	0x1094: "့", 0x1095: "့",
+	0x104E: "၎င်း",
+	0x200B: "",
+}
+
+const kinzi = "င်္"
+
+// zawgyiKinzi maps the kinzi glyphs, typed after the consonant they sit on, to the
+// vowel that comes with them
+var zawgyiKinzi = map[rune]string{0x1064: "", 0x108B: "ိ", 0x108C: "ီ", 0x108D: "ံ"}
+
+// Spelling fixes for characters Zawgyi users type as look-alikes
+var zawgyiFixes = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile("ဦ"), "ဦ"},          // ဥ + ီ = ဦ
+	{regexp.MustCompile("ဥ([်္])"), "ဉ$1"},   // ဥ for ဉ before asat or stack
+	{regexp.MustCompile("စျ"), "ဈ"},          // စ + ျ = ဈ
+	{regexp.MustCompile("၀([ါ-ဲံ])"), "ဝ$1"}, // zero for WA
+	{regexp.MustCompile("၇([ါ-ဲံ])"), "ရ$1"}, // seven for RA
+}
+
+// ZawgyiToUnicode converts Zawgyi-encoded text to Unicode
+func ZawgyiToUnicode(text string) string {
+	var out []rune
+	var held []rune // vowel E and medial RA typed before their consonant
+	lastBase := -1  // index in out of the last consonant
+	release := false
+
+	for _, r := range text {
+		if vowel, ok := zawgyiKinzi[r]; ok {
+			// Kinzi is stored before the consonant it is written on
+			at := max(lastBase, 0)
+			out = append(out[:at], append([]rune(kinzi), out[at:]...)...)
+			lastBase += len([]rune(kinzi))
+			out = append(out, []rune(vowel)...)
+			continue
+		}
+
+		mapped := []rune(string(r))
+		if m, ok := zawgyiMap[r]; ok {
+			mapped = []rune(m)
+		}
+		if len(mapped) == 0 {
+			continue
+		}
+
+		switch first := mapped[0]; {
+		case len(mapped) == 1 && (first == 0x1031 || first == 0x103C):
+			held = append(held, first)
+			continue
+		case first == 0x1039:
+			// Stacked consonants belong to the base, before any held vowel
+			out = append(out, mapped...)
+			continue
+		case isMyanmarConsonant(first):
+			if release {
+				out = append(out, held...)
+				held, release = nil, false
+			}
+			lastBase = len(out)
+			out = append(out, mapped...)
+			release = len(held) > 0
+			continue
+		}
+
+		if release || !isMyanmarMark(mapped[0]) {
+			out = append(out, held...)
+			held, release = nil, false
+		}
+		out = append(out, mapped...)
+	}
+	out = append(out, held...)
+
+	result := string(out)
+	for _, f := range zawgyiFixes {
+		result = f.re.ReplaceAllString(result, f.repl)
+	}
+	return result
+}
+
+// markOrder is the Unicode storage order of Myanmar dependent signs after the
+// consonant and its stacked consonants (UTN #11)
+var markOrder = map[rune]int{
+	0x103B: 1, 0x103C: 2, 0x103D: 3, 0x103E: 4, // medials Y, R, W, H
+	0x1031: 5,                       // vowel E
+	0x102D: 6, 0x102E: 6, 0x1032: 6, // upper vowels
+	0x102F: 7, 0x1030: 7, // lower vowels
+	0x102B: 8, 0x102C: 8, // vowel A
+	0x1036: 9,  // anusvara
+	0x1037: 10, // dot below
+	0x103A: 11, // asat
+	0x1038: 12, // visarga
+}
+
+func isMyanmarMark(r rune) bool {
+	_, ok := markOrder[r]
+	return ok
+}
+
+// NormalizeMyanmarOrder sorts the signs of each syllable into storage order and drops
+// repeated signs, so text typed in a different order renders and compares the same
+func NormalizeMyanmarOrder(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !isMyanmarConsonant(r) {
+			b.WriteRune(r)
+			i++
+			continue
+		}
+
+		// Kinzi, consonant and stacked consonants stay as they are
+		start := i
+		if r == 0x1004 && i+2 < len(runes) && runes[i+1] == 0x103A && runes[i+2] == 0x1039 {
+			i += 3
+			if i < len(runes) && isMyanmarConsonant(runes[i]) {
+				i++
+			}
+		} else {
+			i++
+		}
+		for i+1 < len(runes) && runes[i] == 0x1039 && isMyanmarConsonant(runes[i+1]) {
+			i += 2
+		}
+		b.WriteString(string(runes[start:i]))
+
+		// Then its signs, sorted
+		markStart := i
+		for i < len(runes) && isMyanmarMark(runes[i]) {
+			if runes[i] == 0x103A && i+1 < len(runes) && runes[i+1] == 0x1039 {
+				break // asat of a following kinzi
+			}
+			i++
+		}
+		units := sortMarks(runes[markStart:i])
+		for k, unit := range units {
+			if k > 0 && slices.Equal(unit, units[k-1]) {
+				continue
+			}
+			b.WriteString(string(unit))
+		}
+	}
+	return b.String()
+}
+
+// sortMarks sorts a syllable's signs into storage order. Asat followed by vowel U, as
+// in ကျွန်ုပ်, is how that spelling is stored and moves as one sign.
+func sortMarks(marks []rune) [][]rune {
+	type unit struct {
+		runes []rune
+		order int
+	}
+	var units []unit
+	for k := 0; k < len(marks); k++ {
+		if marks[k] == 0x103A && k+1 < len(marks) && marks[k+1] == 0x102F {
+			units = append(units, unit{marks[k : k+2], markOrder[0x102F]})
+			k++
+			continue
+		}
+		units = append(units, unit{marks[k : k+1], markOrder[marks[k]]})
+	}
+	sort.SliceStable(units, func(a, c int) bool { return units[a].order < units[c].order })
+
+	sorted := make([][]rune, len(units))
+	for k, u := range units {
+		sorted[k] = u.runes
+	}
+	return sorted
+}
+
+// normalizeSegments applies the language's normalization to segment texts
+func normalizeSegments(lang Language, segments []Segment) []Segment {
+	if lang.Normalize == nil {
+		return segments
+	}
+	normalized := make([]Segment, len(segments))
+	for i, s := range segments {
+		s.Text = lang.Normalize(s.Text)
+		normalized[i] = s
+	}
+	return normalized
+}
+
+// normalizeSubtitleFile writes a normalized copy of a subtitle file for rendering,
+// line by line so a file mixing encodings is converted correctly. It returns file
+// itself when nothing changes.
+func normalizeSubtitleFile(lang Language, file string) (string, func(), error) {
+	if lang.Normalize == nil {
+		return file, func() {}, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		lines[i] = lang.Normalize(line)
+	}
+	normalized := strings.Join(lines, "\n")
+	if normalized == string(data) {
+		return file, func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), "normalized_*"+filepath.Ext(file))
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+	if _, err := tmp.WriteString(normalized); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
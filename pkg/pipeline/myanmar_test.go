@@ -0,0 +1,69 @@
+package pipeline
+
+import "testing"
+
+func TestNormalizeMyanmarOrder(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"asat before u", "ကျွန်ုပ်", "ကျွန်ုပ်"},
+		{"medials", "မြန်မာ", "မြန်မာ"},
+		{"i before u", "နိုင်ငံ", "နိုင်ငံ"},
+		{"dot below before asat", "ပြန့်", "ပြန့်"},
+		{"vowel e and a", "ကျောင်း", "ကျောင်း"},
+		{"anusvara after u", "ထုံး", "ထုံး"},
+		{"kinzi", "မင်္ဂလာပါ", "မင်္ဂလာပါ"},
+		{"stacked consonant", "ဗုဒ္ဓ", "ဗုဒ္ဓ"},
+		{"u typed before i", "နုိင်ငံ", "နိုင်ငံ"},
+		{"dot below typed after asat", "ပြန့််", "ပြန့်"},
+		{"medial typed after vowel e", "ကေျာင်း", "ကျောင်း"},
+		{"repeated sign", "ကိိ", "ကိ"},
+		{"not Burmese", "hello", "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeMyanmarOrder(tt.in); got != tt.want {
+				t.Errorf("NormalizeMyanmarOrder(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsZawgyi(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"မင်္ဂလာပါ", false},
+		{"ကျွန်ုပ်", false},
+		{"မြန်မာနိုင်ငံ", false},
+		{"ေက်ာင္း", true},
+		{"ျမန္မာ", true},
+		{"မဂၤလာပါ", true},
+		{"hello", false},
+	}
+	for _, tt := range tests {
+		if got := IsZawgyi(tt.in); got != tt.want {
+			t.Errorf("IsZawgyi(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeBurmese(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"မဂၤလာပါ", "မင်္ဂလာပါ"},
+		{"ေက်ာင္း", "ကျောင်း"},
+		{"ျမန္မာ", "မြန်မာ"},
+		{"ကၽြန္ေတာ္", "ကျွန်တော်"},
+		{"ကျွန်ုပ်", "ကျွန်ုပ်"},
+		{"မြန်မာ", "မြန်မာ"},
+		{"Hello world", "Hello world"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeBurmese(tt.in); got != tt.want {
+			t.Errorf("NormalizeBurmese(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultNameTemplate names output folders and files after the video title
+const DefaultNameTemplate = "{title}"
+
+// maxFileNameRunes limits sanitized names; Burmese runes take 3 bytes, so this stays
+// well below the usual 255-byte file name limit even with suffixes like "_with_subs.mp4"
+const maxFileNameRunes = 60
+
+// SanitizeFileName turns a title into a file name. Letters, digits and combining marks
+// of any script are kept (Burmese vowel signs are marks), whitespace becomes "_" and
+// everything else is dropped. Long names are cut on a character boundary.
+func SanitizeFileName(name string) string {
+	var b strings.Builder
+	lastUnderscore := true // also trims leading underscores
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r) || r == '-':
+			b.WriteRune(r)
+			lastUnderscore = false
+		case unicode.IsSpace(r) || r == '_':
+			if !lastUnderscore {
+				b.WriteRune('_')
+				lastUnderscore = true
+			}
+		}
+	}
+	runes := []rune(strings.TrimRight(b.String(), "_"))
+
+	if len(runes) > maxFileNameRunes {
+		cut := maxFileNameRunes
+		// Don't separate a consonant from its vowel signs and other marks
+		for cut > 0 && unicode.IsMark(runes[cut]) {
+			cut--
+		}
+		runes = runes[:cut]
+	}
+	return strings.Trim(string(runes), "_-")
+}
+
+// ExpandNameTemplate fills {title} and {id} in a naming template, e.g. "{id}-{title}".
+// Both values are sanitized; a title with nothing usable left falls back to the video ID.
+func ExpandNameTemplate(template, title, id string) string {
+	if template == "" {
+		template = DefaultNameTemplate
+	}
+	title, id = SanitizeFileName(title), SanitizeFileName(id)
+	if title == "" && !strings.Contains(template, "{id}") {
+		title = id
+	}
+	name := strings.NewReplacer("{title}", title, "{id}", id).Replace(template)
+	name = SanitizeFileName(name)
+	if name == "" {
+		name = "video"
+	}
+	return name
+}
+
+var outputDirMu sync.Mutex // batch jobs claim output directories one at a time
+
+// claimOutputDir returns the output directory for a video, creating it and recording
+// the video in its manifest. When baseName is already used by a different video,
+// "_2", "_3", ... are appended, so two videos never overwrite each other's files.
+func claimOutputDir(root, baseName, videoKey, source string) (dir, name string, err error) {
+	outputDirMu.Lock()
+	defer outputDirMu.Unlock()
+
+	for n := 1; ; n++ {
+		name = baseName
+		if n > 1 {
+			name = fmt.Sprintf("%s_%d", baseName, n)
+		}
+		dir = filepath.Join(root, name)
+		manifestPath := filepath.Join(dir, ManifestFile)
+
+		m, err := loadManifest(manifestPath)
+		if err != nil {
+			return "", "", err
+		}
+		if m.VideoKey != "" && m.VideoKey != videoKey {
+			continue // taken by another video
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", "", fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if m.VideoKey == "" {
+			m.VideoKey, m.Source = videoKey, source
+			if err := m.save(manifestPath); err != nil {
+				return "", "", err
+			}
+		}
+		return dir, name, nil
+	}
+}
@@ -0,0 +1,630 @@
+// Package pipeline turns a YouTube or local video into a Burmese (or other target
+// language) version: download, Speech-to-Text, translation, Text-to-Speech dubbing,
+// merging and subtitle burn-in.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/banyar-sithu/video/pkg/subtitle"
+	"github.com/kkdai/youtube/v2"
+)
+
+// Options configures a Pipeline
+type Options struct {
+	Source     string // YouTube URL, YouTube video ID, or path to a local video or audio file
+	OutputRoot string // parent of the per-video output directory (default "ToBurmeseVideoOutput")
+
+	// NameTemplate names the output directory and files; {title} and {id} are replaced
+	// by the sanitized title and YouTube video ID (default DefaultNameTemplate)
+	NameTemplate string
+
+	// SourceLanguage is spoken in the video (default English, or AutoLanguage to let
+	// Whisper detect it); TargetLanguage is translated to (default Burmese). Both name
+	// the output files and pick caption tracks; the backends are configured separately.
+	SourceLanguage Language
+	TargetLanguage Language
+
+	Transcriber   Transcriber
+	Translator    Translator
+	Synthesizer   Synthesizer
+	Voice         Voice
+	SubtitleStyle SubtitleStyle
+
+	// SubtitleLayout wraps and times the target subtitles for reading (default
+	// DefaultSubtitleLayout; pixel widths are measured at SubtitleStyle.FontSize)
+	SubtitleLayout subtitle.Layout
+
+	// Bilingual also writes subtitles with the source text above the translation, in a
+	// smaller style (ASS and WebVTT), and burns those instead of the translation alone
+	Bilingual bool
+
+	Download    DownloadOptions  // YouTube stream selection (default DefaultDownloadOptions)
+	Translation TranslateOptions // retries and failure policy (default DefaultTranslateOptions)
+
+	// Glossary terms are protected from the Translator and replaced by their required
+	// translations; segments that still miss a term are listed in the glossary report
+	Glossary *Glossary
+
+	// Memory answers segments translated before (by the same backend and language pair)
+	// without calling the Translator; nil disables it
+	Memory *TranslationMemory
+
+	// Captions lets YouTube sources use an existing source-language caption track instead
+	// of the Transcriber (default DefaultCaptionMode). When the uploader provides a track in
+	// the target language, UseTargetCaptions is asked whether to use it instead of
+	// translating; nil always translates.
+	Captions          CaptionMode
+	UseTargetCaptions func(title, track string) bool
+
+	// FromStage forces that stage and every later one to run again;
+	// OnlyStage runs just that stage on the outputs of an earlier run
+	FromStage Stage
+	OnlyStage Stage
+
+	YouTube  *youtube.Client // default &youtube.Client{}
+	Progress io.Writer       // human-readable progress; nil discards it
+}
+
+// Result lists everything a run produced. Source* fields hold the source language and
+// Target* fields the target language.
+type Result struct {
+	Title     string
+	OutputDir string
+
+	VideoFile      string // original video (or the local input file)
+	SourceText     string // transcript in the source language
+	SourceSRT      string
+	TargetText     string // translation
+	TargetSRT      string
+	TargetLayout   string // TargetSRT wrapped and timed for reading; the subtitles that are burned
+	TargetAudio    string
+	BilingualASS   string // source above target subtitles; empty unless Options.Bilingual
+	BilingualVTT   string
+	DubbedVideo    string // video with the dubbed audio; empty for audio-only input
+	SubtitledVideo string // final video with the dubbed audio and burned subtitles; empty for audio-only input
+
+	SourceSegmentsFile string // timed segments (JSON) used to resume later stages
+	TargetSegmentsFile string
+	Manifest           string
+	GlossaryReport     string // CSV of glossary violations; empty without a glossary
+
+	SourceSegments []Segment
+	TargetSegments []Segment
+
+	// Translation counts segments that were skipped or kept in the source language
+	// because their translation failed (see TranslateOptions.OnError)
+	Translation TranslationStats
+
+	GlossaryViolations []GlossaryViolation
+}
+
+// Pipeline runs every stage for one video
+type Pipeline struct {
+	opts Options
+
+	manifest     *Manifest
+	manifestPath string
+}
+
+// New checks the options and fills in defaults
+func New(opts Options) (*Pipeline, error) {
+	if opts.Source == "" {
+		return nil, errors.New("pipeline: no video source")
+	}
+	if opts.Transcriber == nil || opts.Translator == nil || opts.Synthesizer == nil {
+		return nil, errors.New("pipeline: Transcriber, Translator and Synthesizer are required")
+	}
+	if err := CheckRate(opts.Voice.RatePercent); err != nil {
+		return nil, fmt.Errorf("pipeline: %w", err)
+	}
+	if opts.OutputRoot == "" {
+		opts.OutputRoot = "ToBurmeseVideoOutput"
+	}
+	if opts.NameTemplate == "" {
+		opts.NameTemplate = DefaultNameTemplate
+	}
+	if !strings.Contains(opts.NameTemplate, "{title}") && !strings.Contains(opts.NameTemplate, "{id}") {
+		return nil, fmt.Errorf("pipeline: name template %q needs {title} or {id}", opts.NameTemplate)
+	}
+	if opts.SourceLanguage.Code == "" {
+		opts.SourceLanguage = Languages["en"]
+	}
+	if opts.TargetLanguage.Code == "" {
+		opts.TargetLanguage = Languages["my"]
+	}
+	if opts.TargetLanguage.Code == AutoLanguage.Code {
+		return nil, errors.New("pipeline: the target language cannot be auto")
+	}
+	if opts.SubtitleStyle == (SubtitleStyle{}) {
+		opts.SubtitleStyle = DefaultSubtitleStyle
+		opts.SubtitleStyle.FontName = opts.TargetLanguage.FontName
+	}
+	if opts.SubtitleLayout == (subtitle.Layout{}) {
+		opts.SubtitleLayout = DefaultSubtitleLayout
+	}
+	opts.SubtitleLayout.FontSize = float64(opts.SubtitleStyle.FontSize)
+	if opts.Download == (DownloadOptions{}) {
+		opts.Download = DefaultDownloadOptions
+	}
+	switch opts.Download.Container {
+	case "":
+		opts.Download.Container = "mp4"
+	case "mp4", "mkv":
+	default:
+		return nil, fmt.Errorf("pipeline: unsupported container %q (want mp4 or mkv)", opts.Download.Container)
+	}
+	if opts.Translation == (TranslateOptions{}) {
+		opts.Translation = DefaultTranslateOptions
+	}
+	if opts.Translation.OnError == "" {
+		opts.Translation.OnError = OnErrorFail
+	}
+	if _, err := ParseTranslateErrorPolicy(string(opts.Translation.OnError)); err != nil {
+		return nil, fmt.Errorf("pipeline: %w", err)
+	}
+	if opts.Captions == "" {
+		opts.Captions = DefaultCaptionMode
+	}
+	if _, err := ParseCaptionMode(string(opts.Captions)); err != nil {
+		return nil, fmt.Errorf("pipeline: %w", err)
+	}
+	if opts.YouTube == nil {
+		opts.YouTube = &youtube.Client{}
+	}
+	if opts.Progress == nil {
+		opts.Progress = io.Discard
+	}
+	return &Pipeline{opts: opts}, nil
+}
+
+func (p *Pipeline) logf(format string, args ...any) {
+	fmt.Fprintf(p.opts.Progress, format, args...)
+}
+
+// Run downloads the video (unless Source is a local file) and produces every output file.
+// Stages recorded as done in the output directory's manifest, with unchanged inputs,
+// are skipped. Failures are *StageError.
+func (p *Pipeline) Run(ctx context.Context) (*Result, error) {
+	var (
+		videoInfo *youtube.Video
+		err       error
+	)
+	local := isLocalFile(p.opts.Source)
+
+	// Title from the file name, or from video info for YouTube sources
+	title := strings.TrimSuffix(filepath.Base(p.opts.Source), filepath.Ext(p.opts.Source))
+	var videoID, videoKey string
+	if local {
+		if videoKey, err = filepath.Abs(p.opts.Source); err != nil {
+			return nil, err
+		}
+	} else {
+		videoInfo, err = p.opts.YouTube.GetVideoContext(ctx, p.opts.Source)
+		if err != nil {
+			return nil, &StageError{StageDownload, fmt.Errorf("failed to get video info: %w", err)}
+		}
+		title, videoID, videoKey = videoInfo.Title, videoInfo.ID, videoInfo.ID
+	}
+
+	// Create output directory based on video title; another video with the same name gets a suffix
+	outputDir, baseName, err := claimOutputDir(p.opts.OutputRoot, ExpandNameTemplate(p.opts.NameTemplate, title, videoID), videoKey, p.opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	p.logf("📁 Output directory: %s\n", outputDir)
+
+	// File names based on video name (all inside the output folder)
+	src, dst := "_"+p.opts.SourceLanguage.fileSuffix(), "_"+p.opts.TargetLanguage.fileSuffix()
+	res := &Result{
+		Title:              title,
+		OutputDir:          outputDir,
+		VideoFile:          filepath.Join(outputDir, baseName+p.opts.Download.downloadFileExt()),
+		SourceText:         filepath.Join(outputDir, baseName+src+".txt"),
+		SourceSRT:          filepath.Join(outputDir, baseName+src+".srt"),
+		TargetText:         filepath.Join(outputDir, baseName+dst+".txt"),
+		TargetSRT:          filepath.Join(outputDir, baseName+dst+".srt"),
+		TargetLayout:       filepath.Join(outputDir, baseName+dst+".layout.srt"),
+		TargetAudio:        filepath.Join(outputDir, baseName+dst+".mp3"),
+		DubbedVideo:        filepath.Join(outputDir, baseName+dst+"."+p.opts.Download.Container),
+		SubtitledVideo:     filepath.Join(outputDir, baseName+"_with_subs."+p.opts.Download.Container),
+		SourceSegmentsFile: filepath.Join(outputDir, baseName+src+".segments.json"),
+		TargetSegmentsFile: filepath.Join(outputDir, baseName+dst+".segments.json"),
+		Manifest:           filepath.Join(outputDir, ManifestFile),
+	}
+	if p.opts.Bilingual {
+		res.BilingualASS = filepath.Join(outputDir, baseName+src+dst+".ass")
+		res.BilingualVTT = filepath.Join(outputDir, baseName+src+dst+".vtt")
+	}
+
+	p.manifestPath = res.Manifest
+	if p.manifest, err = loadManifest(p.manifestPath); err != nil {
+		return res, err
+	}
+	p.manifest.Source = p.opts.Source
+
+	if local {
+		// Local files go straight to Speech-to-Text
+		res.VideoFile = p.opts.Source
+		p.logf("🎞️ Local file: %s\n", res.VideoFile)
+	} else {
+		// Step 1: YouTube ဒေါင်းလုပ်ခြင်း
+		err = p.runStage(stageSpec{
+			stage:   StageDownload,
+			params:  map[string]string{"video_id": videoInfo.ID, "download": describeBackend(p.opts.Download)},
+			outputs: []string{res.VideoFile},
+			run: func() error {
+				p.logf("🎥 YouTube ဒေါင်းလုပ်နေသည်...\n")
+				p.logf("📹 Title: %s\n", videoInfo.Title)
+				if err := DownloadYouTube(ctx, p.opts.YouTube, videoInfo, res.VideoFile, p.opts.Download, p.opts.Progress); err != nil {
+					return err
+				}
+				p.logf("✅ Video saved: %s\n", res.VideoFile)
+				return nil
+			},
+		})
+		if err != nil {
+			return res, err
+		}
+	}
+
+	// Audio-only input or download: no video for the dub or subtitles
+	if isAudioFile(res.VideoFile) {
+		res.DubbedVideo, res.SubtitledVideo = "", ""
+	}
+
+	// Existing YouTube captions can replace Speech-to-Text and translation.
+	// An auto-detected source language has no track to look for.
+	var sourceTrack, targetTrack *youtube.CaptionTrack
+	if !local {
+		if p.opts.Captions != CaptionsWhisper && p.opts.SourceLanguage.Code != AutoLanguage.Code {
+			sourceTrack = FindCaptionTrack(videoInfo.CaptionTracks, p.opts.SourceLanguage.Code, p.opts.Captions == CaptionsYouTubeAuto)
+			if sourceTrack == nil {
+				p.logf("ℹ️ %s captions မရှိ, Speech-to-Text သုံးမည်\n", p.opts.SourceLanguage.Name)
+			}
+		}
+		if track := FindCaptionTrack(videoInfo.CaptionTracks, p.opts.TargetLanguage.Code, false); track != nil && p.opts.UseTargetCaptions != nil &&
+			p.opts.UseTargetCaptions(title, track.Name.SimpleText) {
+			targetTrack = track
+		}
+	}
+
+	// Step 2: Speech-to-Text
+	transcribe := stageSpec{
+		stage:   StageTranscribe,
+		params:  map[string]string{"transcriber": describeBackend(p.opts.Transcriber)},
+		inputs:  []string{res.VideoFile},
+		outputs: []string{res.SourceText, res.SourceSRT, res.SourceSegmentsFile},
+		run: func() error {
+			p.logf("\n🎤 Speech-to-Text ဆောင်ရွက်နေသည်...\n")
+			segments, err := p.opts.Transcriber.Transcribe(ctx, res.VideoFile)
+			if err != nil {
+				return err
+			}
+			return p.saveSource(res, segments)
+		},
+	}
+	if sourceTrack != nil {
+		transcribe.params = map[string]string{"captions": describeCaptionTrack(sourceTrack)}
+		transcribe.inputs = nil
+		transcribe.run = func() error {
+			p.logf("\n💬 %s ကို သုံးနေသည်...\n", describeCaptionTrack(sourceTrack))
+			segments, err := FetchCaptions(ctx, p.opts.YouTube, sourceTrack)
+			if err != nil {
+				return err
+			}
+			return p.saveSource(res, segments)
+		}
+	}
+	if err = p.runStage(transcribe); err != nil {
+		return res, err
+	}
+
+	// Step 3: Translation (source → target language), segment by segment to keep timing
+	// Segments a chunk failed for keep the stage from counting as done, so the next
+	// run translates again
+	partial := false
+	translate := stageSpec{
+		stage:   StageTranslate,
+		params:  map[string]string{"translator": describeBackend(p.opts.Translator), "on_error": string(p.opts.Translation.OnError)},
+		inputs:  []string{res.SourceSegmentsFile},
+		outputs: []string{res.TargetText, res.TargetSRT, res.TargetSegmentsFile},
+		run: func() error {
+			if err := loadSegments(&res.SourceSegments, res.SourceSegmentsFile); err != nil {
+				return err
+			}
+			p.logf("🔤 %s → %s ဘာသာပြန်နေသည်...\n", p.opts.SourceLanguage.Name, p.opts.TargetLanguage.Name)
+			translator := p.opts.Translator
+			if p.opts.Memory != nil {
+				translator = &MemoryTranslator{
+					Translator: translator,
+					Memory:     p.opts.Memory,
+					SourceLang: p.opts.SourceLanguage.Code,
+					TargetLang: p.opts.TargetLanguage.Code,
+				}
+			}
+			if p.opts.Glossary != nil {
+				translator = &GlossaryTranslator{Translator: translator, Glossary: p.opts.Glossary}
+			}
+			translation := p.opts.Translation
+			translation.Video = VideoSummary{Title: title}
+			translation.SentenceEnd = p.opts.SourceLanguage.SentenceEnd
+			if videoInfo != nil {
+				translation.Video.Description = videoInfo.Description
+			}
+			segments, err := TranslateSegments(ctx, translator, res.SourceSegments, translation, p.opts.Progress)
+			if err != nil {
+				return err
+			}
+			partial = CountUntranslated(withSegmentIDs(res.SourceSegments), segments).Untranslated > 0
+			return p.saveTarget(res, normalizeSegments(p.opts.TargetLanguage, segments))
+		},
+		partial: func() bool { return partial },
+	}
+	if p.opts.Glossary != nil {
+		translate.params["glossary"] = describeBackend(p.opts.Glossary.Terms)
+	}
+	if targetTrack != nil {
+		translate.params = map[string]string{"captions": describeCaptionTrack(targetTrack)}
+		translate.inputs = nil
+		translate.run = func() error {
+			p.logf("💬 %s ကို သုံးနေသည်...\n", describeCaptionTrack(targetTrack))
+			segments, err := FetchCaptions(ctx, p.opts.YouTube, targetTrack)
+			if err != nil {
+				return err
+			}
+			return p.saveTarget(res, normalizeSegments(p.opts.TargetLanguage, segments))
+		}
+	}
+	if err = p.runStage(translate); err != nil {
+		return res, err
+	}
+	if targetTrack == nil {
+		if err := p.loadResultSegments(res); err != nil {
+			return res, err
+		}
+		res.Translation = CountUntranslated(withSegmentIDs(res.SourceSegments), res.TargetSegments)
+		if res.Translation.Untranslated > 0 {
+			p.logf("⚠️ %s\n", res.Translation)
+		}
+	}
+
+	// Glossary report for the current translation (it may have been edited by hand)
+	if p.opts.Glossary != nil {
+		if err := p.loadResultSegments(res); err != nil {
+			return res, err
+		}
+		res.GlossaryReport = filepath.Join(outputDir, baseName+"_glossary_violations.csv")
+		res.GlossaryViolations = CheckGlossary(p.opts.Glossary, withSegmentIDs(res.SourceSegments), res.TargetSegments)
+		if err := WriteGlossaryReport(res.GlossaryReport, res.GlossaryViolations); err != nil {
+			return res, err
+		}
+		if n := len(res.GlossaryViolations); n > 0 {
+			p.logf("⚠️ %d glossary violations, see %s\n", n, res.GlossaryReport)
+		}
+	}
+
+	// Step 4: Wrap and time the subtitles for reading
+	layout := stageSpec{
+		stage:   StageLayout,
+		params:  map[string]string{"layout": describeBackend(p.opts.SubtitleLayout)},
+		inputs:  []string{res.TargetSRT},
+		outputs: []string{res.TargetLayout},
+		run: func() error {
+			if err := p.layoutSubtitles(res.TargetSRT, res.TargetLayout); err != nil {
+				return err
+			}
+			if !p.opts.Bilingual {
+				return nil
+			}
+			if err := p.loadResultSegments(res); err != nil {
+				return err
+			}
+			track := BilingualTrack(res.SourceSegments, res.TargetSegments, p.opts.SourceLanguage, p.opts.TargetLanguage, p.opts.SubtitleStyle, p.opts.SubtitleLayout)
+			if err := WriteBilingual(res.BilingualASS, res.BilingualVTT, track); err != nil {
+				return err
+			}
+			p.logf("✅ Bilingual subtitles saved to: %s, %s\n", res.BilingualASS, res.BilingualVTT)
+			return nil
+		},
+	}
+	if p.opts.Bilingual {
+		layout.params["bilingual"] = describeBackend(p.opts.SubtitleStyle)
+		layout.inputs = append(layout.inputs, res.SourceSegmentsFile, res.TargetSegmentsFile)
+		layout.outputs = append(layout.outputs, res.BilingualASS, res.BilingualVTT)
+	}
+	if err = p.runStage(layout); err != nil {
+		return res, err
+	}
+
+	// Step 5: Text-to-Speech, each segment placed at its source start time
+	err = p.runStage(stageSpec{
+		stage: StageSynthesize,
+		params: map[string]string{
+			"synthesizer": describeBackend(p.opts.Synthesizer),
+			"voice":       describeBackend(p.opts.Voice),
+		},
+		inputs:  []string{res.TargetSegmentsFile},
+		outputs: []string{res.TargetAudio},
+		run: func() error {
+			if err := loadSegments(&res.TargetSegments, res.TargetSegmentsFile); err != nil {
+				return err
+			}
+			p.logf("\n🔊 %s TTS ဆောင်ရွက်နေသည် (voice: %s)...\n", p.opts.TargetLanguage.Name, p.opts.Voice.Name)
+			// The segments file may have been edited by hand, in any encoding
+			segments := normalizeSegments(p.opts.TargetLanguage, res.TargetSegments)
+			if err := BuildTimedDub(ctx, segments, p.opts.Synthesizer, p.opts.Voice, res.TargetAudio, p.opts.Progress); err != nil {
+				return err
+			}
+			p.logf("✅ Audio saved to %s\n", res.TargetAudio)
+			return nil
+		},
+	})
+	if err != nil {
+		return res, err
+	}
+
+	// Audio-only: nothing to put the dub or subtitles on
+	if res.DubbedVideo == "" {
+		return res, p.loadResultSegments(res)
+	}
+
+	// Step 6: Merge audio with video; the original audio and both subtitle tracks stay selectable
+	source, target := p.opts.SourceLanguage, p.opts.TargetLanguage
+	merge := MergeOptions{
+		Dub:          StreamTags{Language: target.ISO639, Title: target.Name + " (dubbed)"},
+		KeepOriginal: true,
+		Original:     StreamTags{Language: source.ISO639, Title: source.Name + " (original)"},
+		Subtitles: []SubtitleStream{
+			{File: res.SourceSRT, StreamTags: StreamTags{Language: source.ISO639, Title: source.Name}},
+			{File: res.TargetLayout, StreamTags: StreamTags{Language: target.ISO639, Title: target.Name}},
+		},
+	}
+	err = p.runStage(stageSpec{
+		stage:   StageMerge,
+		params:  map[string]string{"tracks": describeBackend(merge)},
+		inputs:  []string{res.VideoFile, res.TargetAudio, res.SourceSRT, res.TargetLayout},
+		outputs: []string{res.DubbedVideo},
+		run: func() error {
+			p.logf("\n🎬 Video နှင့် Audio ပေါင်းစပ်နေသည်...\n")
+			if err := MergeAudioWithVideo(ctx, res.VideoFile, res.TargetAudio, res.DubbedVideo, merge); err != nil {
+				return err
+			}
+			p.logf("✅ Video with %s audio saved to: %s\n", p.opts.TargetLanguage.Name, res.DubbedVideo)
+			return nil
+		},
+	})
+	if err != nil {
+		return res, err
+	}
+
+	// Step 7: Burn translated subtitles into the video
+	burned := res.TargetLayout
+	if p.opts.Bilingual {
+		burned = res.BilingualASS
+	}
+	err = p.runStage(stageSpec{
+		stage:   StageBurn,
+		params:  map[string]string{"style": describeBackend(p.opts.SubtitleStyle)},
+		inputs:  []string{res.DubbedVideo, burned},
+		outputs: []string{res.SubtitledVideo},
+		run: func() error {
+			p.logf("\n📝 မြန်မာစာတန်းထိုး ထည့်သွင်းနေသည် (font: %s)...\n", p.opts.SubtitleStyle.FontName)
+			subtitles, cleanup, err := normalizeSubtitleFile(p.opts.TargetLanguage, burned)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			if err := BurnSubtitles(ctx, res.DubbedVideo, subtitles, res.SubtitledVideo, p.opts.SubtitleStyle); err != nil {
+				return err
+			}
+			p.logf("✅ Video with %s subtitles saved to: %s\n", p.opts.TargetLanguage.Name, res.SubtitledVideo)
+			return nil
+		},
+	})
+	if err != nil {
+		return res, err
+	}
+
+	return res, p.loadResultSegments(res)
+}
+
+// saveSource writes the source-language text, subtitles and segments
+func (p *Pipeline) saveSource(res *Result, segments []Segment) error {
+	res.SourceSegments = segments
+	if err := writeSegments(res.SourceText, res.SourceSRT, res.SourceSegmentsFile, segments); err != nil {
+		return err
+	}
+	p.logf("✅ %s saved to: %s, %s\n\n", p.opts.SourceLanguage.Name, res.SourceText, res.SourceSRT)
+	return nil
+}
+
+// saveTarget writes the target-language text, subtitles and segments
+func (p *Pipeline) saveTarget(res *Result, segments []Segment) error {
+	res.TargetSegments = segments
+	if err := writeSegments(res.TargetText, res.TargetSRT, res.TargetSegmentsFile, segments); err != nil {
+		return err
+	}
+	p.logf("✅ %s saved to: %s, %s\n\n", p.opts.TargetLanguage.Name, res.TargetText, res.TargetSRT)
+	return nil
+}
+
+// layoutSubtitles wraps the (possibly hand-edited) subtitles in input to the layout's
+// line limits and reading speed, and writes them to output
+func (p *Pipeline) layoutSubtitles(input, output string) error {
+	track, err := subtitle.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	for _, w := range track.Warnings {
+		p.logf("⚠️ %s: %s\n", filepath.Base(input), w)
+	}
+	for i := range track.Cues {
+		track.Cues[i].Text = p.opts.TargetLanguage.NormalizeText(track.Cues[i].Text)
+	}
+
+	cues, report := p.opts.SubtitleLayout.Apply(track.Cues)
+	track.Cues = cues
+	if err := subtitle.WriteFile(output, track); err != nil {
+		return err
+	}
+	p.logf("✅ Subtitles laid out: %d cues (%d split, %d merged, %d extended) saved to %s\n",
+		len(cues), report.Split, report.Merged, report.Extended, output)
+	if report.TooFast > 0 {
+		p.logf("⚠️ %d cues are still too fast to read, even after merging\n", report.TooFast)
+	}
+	return nil
+}
+
+// loadResultSegments fills in segments for stages that were skipped
+func (p *Pipeline) loadResultSegments(res *Result) error {
+	if err := loadSegments(&res.SourceSegments, res.SourceSegmentsFile); err != nil {
+		return err
+	}
+	return loadSegments(&res.TargetSegments, res.TargetSegmentsFile)
+}
+
+// loadSegments reads segments saved by an earlier run unless they are already in memory
+func loadSegments(segments *[]Segment, file string) error {
+	if *segments != nil {
+		return nil
+	}
+	loaded, err := ReadSegmentsJSON(file)
+	if err != nil {
+		return err
+	}
+	*segments = loaded
+	return nil
+}
+
+// isLocalFile reports whether source names an existing file rather than a YouTube URL or ID
+func isLocalFile(source string) bool {
+	info, err := os.Stat(source)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// isAudioFile reports whether a local input is audio only, by extension
+func isAudioFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".mp3", ".wav", ".m4a", ".aac", ".flac", ".ogg", ".opus", ".wma":
+		return true
+	}
+	return false
+}
+
+// writeSegments saves segments as plain text (one per line), as SRT and as JSON
+func writeSegments(textFile, srtFile, jsonFile string, segments []Segment) error {
+	if err := os.WriteFile(textFile, []byte(segmentsText(segments)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", textFile, err)
+	}
+	if err := WriteSRT(srtFile, segments); err != nil {
+		return err
+	}
+	return WriteSegmentsJSON(jsonFile, segments)
+}
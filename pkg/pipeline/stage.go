@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Stage names one step of the pipeline
+type Stage string
+
+const (
+	StageDownload   Stage = "download"
+	StageTranscribe Stage = "transcribe"
+	StageTranslate  Stage = "translate"
+	StageLayout     Stage = "layout"
+	StageSynthesize Stage = "synthesize"
+	StageMerge      Stage = "merge"
+	StageBurn       Stage = "burn"
+)
+
+// Stages lists every stage in run order
+var Stages = []Stage{StageDownload, StageTranscribe, StageTranslate, StageLayout, StageSynthesize, StageMerge, StageBurn}
+
+// ParseStage validates a stage name
+func ParseStage(name string) (Stage, error) {
+	if stage := Stage(name); slices.Contains(Stages, stage) {
+		return stage, nil
+	}
+	return "", fmt.Errorf("unknown stage %q (want one of %v)", name, Stages)
+}
+
+// StageError reports which stage of a run failed
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// stageSpec describes one stage run: what it reads, what it writes and how
+type stageSpec struct {
+	stage   Stage
+	params  map[string]string
+	inputs  []string
+	outputs []string
+	run     func() error
+	partial func() bool // reports after run whether the outputs are incomplete
+}
+
+// forced reports whether --from-stage/--only-stage require stage to run again
+func (p *Pipeline) forced(stage Stage) bool {
+	if p.opts.OnlyStage != "" {
+		return stage == p.opts.OnlyStage
+	}
+	if p.opts.FromStage != "" {
+		return slices.Index(Stages, stage) >= slices.Index(Stages, p.opts.FromStage)
+	}
+	return false
+}
+
+// runStage runs a stage unless the manifest shows it is up to date, then records the outcome
+func (p *Pipeline) runStage(spec stageSpec) error {
+	m := p.manifest
+
+	switch {
+	case p.opts.OnlyStage != "" && spec.stage != p.opts.OnlyStage:
+		// Only one stage runs; the others just provide their earlier outputs
+		if !outputsExist(spec.outputs) {
+			return &StageError{spec.stage, fmt.Errorf("outputs missing; run this stage before --only-stage %s", p.opts.OnlyStage)}
+		}
+		return nil
+	case !p.forced(spec.stage) && m.upToDate(spec.stage, spec.params, spec.inputs, spec.outputs):
+		p.logf("⏭️ %s: up to date, skipped\n", spec.stage)
+		return nil
+	}
+
+	rec := &StageRecord{Params: spec.params, StartedAt: time.Now()}
+	inputs, err := m.recordFiles(spec.inputs)
+	if err != nil {
+		return &StageError{spec.stage, err}
+	}
+	rec.Inputs = inputs
+
+	runErr := spec.run()
+	if runErr == nil {
+		rec.Outputs, runErr = m.recordFiles(spec.outputs)
+	}
+	rec.FinishedAt = time.Now()
+	rec.Status = StatusDone
+	if runErr == nil && spec.partial != nil && spec.partial() {
+		rec.Status = StatusPartial
+		p.logf("⚠️ %s: incomplete, it runs again next time\n", spec.stage)
+	}
+	if runErr != nil {
+		rec.Status = StatusFailed
+		rec.Error = runErr.Error()
+	}
+
+	m.Stages[spec.stage] = rec
+	if err := m.save(p.manifestPath); err != nil && runErr == nil {
+		runErr = fmt.Errorf("failed to save manifest: %w", err)
+	}
+	if runErr != nil {
+		return &StageError{spec.stage, runErr}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/banyar-sithu/video/pkg/subtitle"
+)
+
+// Segment is a piece of timed text (Whisper segment သို့မဟုတ် ဘာသာပြန်ထားသော segment)
+type Segment struct {
+	ID    int           `json:"id"` // 1-based position in the transcript; translations keep it
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+	Text  string        `json:"text"`
+
+	Untranslated bool `json:"untranslated,omitempty"` // translation failed; Text is still the source text
+}
+
+// whisperJSON matches the subset of Whisper's --output_format json (and verbose_json) we need
+type whisperJSON struct {
+	Text     string `json:"text"`
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+}
+
+// readWhisperJSON loads the timed segments written by Whisper
+func readWhisperJSON(jsonFile string) ([]Segment, error) {
+	data, err := os.ReadFile(jsonFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed whisperJSON
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", jsonFile, err)
+	}
+	return parsed.toSegments(), nil
+}
+
+// toSegments converts Whisper's seconds-based segments, dropping empty ones
+func (w whisperJSON) toSegments() []Segment {
+	var segments []Segment
+	for _, s := range w.Segments {
+		text := strings.TrimSpace(s.Text)
+		if text == "" {
+			continue
+		}
+		segments = append(segments, Segment{
+			ID:    len(segments) + 1,
+			Start: secondsToDuration(s.Start),
+			End:   secondsToDuration(s.End),
+			Text:  text,
+		})
+	}
+	return segments
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// segmentsText joins segment texts one per line, like Whisper's txt output
+func segmentsText(segments []Segment) string {
+	lines := make([]string, len(segments))
+	for i, s := range segments {
+		lines[i] = s.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSRTTimestamp formats a duration as HH:MM:SS,mmm
+func formatSRTTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, (ms/60000)%60, (ms/1000)%60, ms%1000)
+}
+
+// WriteSRT saves segments as a SubRip (.srt) subtitle file
+func WriteSRT(outputFile string, segments []Segment) error {
+	return subtitle.WriteFile(outputFile, segmentsTrack(segments))
+}
+
+// segmentsTrack turns segments into a subtitle track, one cue per segment; segment text
+// is plain, so nothing in it is taken for markup
+func segmentsTrack(segments []Segment) *subtitle.Track {
+	track := &subtitle.Track{Cues: make([]subtitle.Cue, len(segments))}
+	for i, s := range segments {
+		track.Cues[i] = subtitle.Cue{Start: s.Start, End: s.End, Text: subtitle.Escape(s.Text)}
+	}
+	return track
+}
+
+// WriteSegmentsJSON saves segments with their timing so later runs can reload them
+func WriteSegmentsJSON(outputFile string, segments []Segment) error {
+	data, err := json.MarshalIndent(segments, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+	return nil
+}
+
+// ReadSegmentsJSON loads segments saved by WriteSegmentsJSON
+func ReadSegmentsJSON(file string) ([]Segment, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var segments []Segment
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return segments, nil
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Voice selects how a Synthesizer speaks
+type Voice struct {
+	Name        string // engine-specific voice (edge-tts voice, espeak-ng voice, piper .onnx model)
+	RatePercent int    // speed change, e.g. -10 for 10% slower
+	PitchHz     int    // pitch shift in Hz, e.g. +5
+}
+
+// CheckRate rejects speed changes of -100% or less, which would stop the speech or run
+// it backwards
+func CheckRate(percent int) error {
+	if percent <= -100 {
+		return fmt.Errorf("speech rate change %d%% must be above -100%%", percent)
+	}
+	return nil
+}
+
+// Synthesizer renders text to speech (Text-to-Speech).
+// The audio container is the engine's own (mp3 for edge-tts, wav for the others);
+// callers pass the file to ffmpeg/ffplay, which detect the format.
+type Synthesizer interface {
+	Synthesize(ctx context.Context, text string, voice Voice, outputFile string) error
+}
+
+// EdgeSynthesizer uses Microsoft Edge's online TTS through edge-tts (writes mp3)
+type EdgeSynthesizer struct {
+	Path string
+}
+
+func (s *EdgeSynthesizer) Synthesize(ctx context.Context, text string, voice Voice, outputFile string) error {
+	// --rate: speech speed (-50% to +100%), --pitch: voice pitch
+	cmd := exec.CommandContext(ctx, s.Path,
+		"--voice", voice.Name,
+		"--text", text,
+		"--write-media", outputFile,
+		fmt.Sprintf("--rate=%+d%%", voice.RatePercent),
+		fmt.Sprintf("--pitch=%+dHz", voice.PitchHz),
+	)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("edge-tts error: %w", err)
+	}
+	return nil
+}
+
+// EspeakSynthesizer uses the offline espeak-ng engine (writes wav)
+type EspeakSynthesizer struct {
+	Path string
+}
+
+func (s *EspeakSynthesizer) Synthesize(ctx context.Context, text string, voice Voice, outputFile string) error {
+	// espeak-ng defaults: 175 words per minute, pitch 50 (0-99)
+	wpm := 175 * (100 + voice.RatePercent) / 100
+	pitch := min(max(50+voice.PitchHz/2, 0), 99)
+
+	cmd := exec.CommandContext(ctx, s.Path,
+		"-v", voice.Name,
+		"-s", strconv.Itoa(wpm),
+		"-p", strconv.Itoa(pitch),
+		"-w", outputFile,
+		"--stdin",
+	)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("espeak-ng error: %w", err)
+	}
+	return nil
+}
+
+// PiperSynthesizer uses the offline Piper neural engine (writes wav).
+// Piper has no pitch control, so PitchHz is ignored.
+type PiperSynthesizer struct {
+	Path string
+}
+
+func (s *PiperSynthesizer) Synthesize(ctx context.Context, text string, voice Voice, outputFile string) error {
+	// length_scale > 1 is slower, so invert the rate
+	lengthScale := 100.0 / float64(100+voice.RatePercent)
+
+	cmd := exec.CommandContext(ctx, s.Path,
+		"--model", voice.Name,
+		"--length_scale", strconv.FormatFloat(lengthScale, 'f', 3, 64),
+		"--output_file", outputFile,
+	)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("piper error: %w", err)
+	}
+	return nil
+}
+
+// FakeSynthesizer writes a deterministic beep instead of speech: fakeRuneDuration
+// of tone per rune of text. It needs no network or external tools, for tests and dry runs.
+type FakeSynthesizer struct{}
+
+const (
+	fakeSampleRate   = 16000
+	fakeRuneDuration = 0.06 // seconds per rune
+	fakeToneHz       = 440
+)
+
+func (s *FakeSynthesizer) Synthesize(ctx context.Context, text string, voice Voice, outputFile string) error {
+	seconds := float64(utf8.RuneCountInString(strings.TrimSpace(text))) * fakeRuneDuration
+	seconds *= 100 / float64(100+voice.RatePercent)
+	samples := int(seconds * fakeSampleRate)
+
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := writeWavHeader(w, samples, fakeSampleRate); err != nil {
+		return err
+	}
+	for i := 0; i < samples; i++ {
+		v := int16(math.Sin(2*math.Pi*fakeToneHz*float64(i)/fakeSampleRate) * 8000)
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// writeWavHeader writes a 16-bit mono PCM wav header
+func writeWavHeader(w *bufio.Writer, samples, sampleRate int) error {
+	dataSize := uint32(samples * 2)
+	header := []any{
+		[]byte("RIFF"), 36 + dataSize, []byte("WAVE"),
+		[]byte("fmt "), uint32(16), uint16(1), uint16(1), uint32(sampleRate), uint32(sampleRate * 2), uint16(2), uint16(16),
+		[]byte("data"), dataSize,
+	}
+	for _, v := range header {
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFakeSynthesizer(t *testing.T) {
+	tests := []struct {
+		text    string
+		rate    int
+		samples int
+	}{
+		{"Hello", 0, 4800},
+		{"  Hello  ", 0, 4800},
+		{"မြန်မာ", 0, 5760},
+		{"Hello", 100, 2400},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "clip.wav")
+		if err := (&FakeSynthesizer{}).Synthesize(context.Background(), tt.text, Voice{RatePercent: tt.rate}, file); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := (len(data) - 44) / 2; got != tt.samples || string(data[:4]) != "RIFF" {
+			t.Errorf("Synthesize(%q, rate %d) wrote %d samples, want %d", tt.text, tt.rate, got, tt.samples)
+		}
+	}
+}
+
+func TestCheckRate(t *testing.T) {
+	tests := []struct {
+		percent int
+		ok      bool
+	}{
+		{-10, true},
+		{0, true},
+		{200, true},
+		{-99, true},
+		{-100, false},
+		{-150, false},
+	}
+	for _, tt := range tests {
+		if err := CheckRate(tt.percent); (err == nil) != tt.ok {
+			t.Errorf("CheckRate(%d) = %v, want ok %v", tt.percent, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// TMX 1.4 documents, the exchange format of translation memory tools
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Units   []tmxUnit `xml:"body>tu"`
+}
+
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTMF                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+}
+
+type tmxUnit struct {
+	CreationDate  string       `xml:"creationdate,attr,omitempty"`
+	LastUsageDate string       `xml:"lastusagedate,attr,omitempty"`
+	UsageCount    int          `xml:"usagecount,attr,omitempty"`
+	Props         []tmxProp    `xml:"prop"`
+	Variants      []tmxVariant `xml:"tuv"`
+}
+
+type tmxProp struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// tmxVariant writes xml:lang; on reading Go reports the attribute in the XML namespace.
+// TMX 1.1 files use a plain lang attribute.
+type tmxVariant struct {
+	Lang    string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	OldLang string `xml:"lang,attr,omitempty"`
+	Seg     string `xml:"seg"`
+}
+
+type tmxVariantOut struct {
+	Lang string `xml:"xml:lang,attr"`
+	Seg  string `xml:"seg"`
+}
+
+// MarshalXML writes the xml:lang attribute with its usual prefix
+func (v tmxVariant) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(tmxVariantOut{Lang: v.Lang, Seg: v.Seg}, start)
+}
+
+const tmxDate = "20060102T150405Z"
+
+// ExportTMX writes entries as a TMX 1.4 document. The backend is kept in an
+// x-backend property so an import restores the same memory keys.
+func ExportTMX(w io.Writer, entries []MemoryEntry) error {
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:        "video",
+			CreationToolVersion: "1",
+			SegType:             "sentence",
+			OTMF:                "jsonl",
+			AdminLang:           "en",
+			SrcLang:             "*all*",
+			DataType:            "plaintext",
+		},
+	}
+	for _, e := range entries {
+		doc.Units = append(doc.Units, tmxUnit{
+			CreationDate:  e.Created.UTC().Format(tmxDate),
+			LastUsageDate: e.LastUsed.UTC().Format(tmxDate),
+			UsageCount:    e.Hits,
+			Props:         []tmxProp{{Type: "x-backend", Value: e.Backend}},
+			Variants: []tmxVariant{
+				{Lang: e.SourceLang, Seg: e.Source},
+				{Lang: e.TargetLang, Seg: e.Target},
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ImportTMX reads the translation units of a TMX document. The first variant of a unit
+// is the source (or the one in the header's srclang) and the second the target. Units
+// without an x-backend property get backend. Language tags are stored as registry codes
+// ("en-US" as "en"), and texts are normalized for their language.
+func ImportTMX(r io.Reader, backend string) ([]MemoryEntry, error) {
+	var doc tmxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid TMX: %w", err)
+	}
+
+	var entries []MemoryEntry
+	for i, u := range doc.Units {
+		if len(u.Variants) < 2 {
+			continue
+		}
+		source, target := u.Variants[0], u.Variants[1]
+		if memoryLang(variantLang(target)) == memoryLang(doc.Header.SrcLang) {
+			source, target = target, source
+		}
+
+		e := MemoryEntry{
+			Backend:    backend,
+			SourceLang: memoryLang(variantLang(source)),
+			TargetLang: memoryLang(variantLang(target)),
+			Source:     normalizeIn(variantLang(source), source.Seg),
+			Target:     normalizeIn(variantLang(target), target.Seg),
+			Hits:       u.UsageCount,
+		}
+		if e.SourceLang == "" || e.TargetLang == "" {
+			return nil, fmt.Errorf("invalid TMX: translation unit %d has no language", i+1)
+		}
+		for _, p := range u.Props {
+			if p.Type == "x-backend" && p.Value != "" {
+				e.Backend = p.Value
+			}
+		}
+		e.Created, _ = time.Parse(tmxDate, u.CreationDate)
+		e.LastUsed, _ = time.Parse(tmxDate, u.LastUsageDate)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func variantLang(v tmxVariant) string {
+	if v.Lang != "" {
+		return v.Lang
+	}
+	return v.OldLang
+}
+
+// memoryLang maps a TMX language tag ("my", "my-MM", "en_US") to the registry code the
+// memory is keyed by. Languages the registry does not know keep their primary subtag.
+func memoryLang(tag string) string {
+	primary, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	if lang, err := LookupLanguage(primary, false); err == nil {
+		return lang.Code
+	}
+	return strings.ToLower(primary)
+}
+
+// normalizeIn normalizes imported text by its TMX language
+func normalizeIn(tag, text string) string {
+	lang, err := LookupLanguage(memoryLang(tag), false)
+	if err != nil {
+		return text
+	}
+	return lang.NormalizeText(text)
+}
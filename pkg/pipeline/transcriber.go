@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Transcriber turns an audio or video file into timed segments (Speech-to-Text)
+type Transcriber interface {
+	Transcribe(ctx context.Context, audioFile string) ([]Segment, error)
+}
+
+// WhisperCLI runs the openai-whisper CLI (the model is loaded on every call)
+type WhisperCLI struct {
+	Path     string
+	Model    string
+	Language string // empty lets Whisper detect the language
+}
+
+func (t *WhisperCLI) Transcribe(ctx context.Context, audioFile string) ([]Segment, error) {
+	outputDir, err := os.MkdirTemp("", "whisper_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(outputDir)
+
+	// JSON output keeps the segment timestamps needed for subtitles
+	args := []string{audioFile, "--output_format", "json", "--output_dir", outputDir}
+	if t.Language != "" {
+		args = append(args, "--language", t.Language)
+	}
+	if t.Model != "" {
+		args = append(args, "--model", t.Model)
+	}
+	cmd := exec.CommandContext(ctx, t.Path, args...)
+
+	// Pipe stdout and stderr to show progress in real-time
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("whisper error: %w", err)
+	}
+
+	// whisper creates the file based on the input filename
+	baseName := strings.TrimSuffix(filepath.Base(audioFile), filepath.Ext(audioFile))
+	return readWhisperJSON(filepath.Join(outputDir, baseName+".json"))
+}
+
+// WhisperServer posts audio to a running whisper server, which keeps the
+// model loaded between requests. Works with whisper.cpp's /inference endpoint and
+// OpenAI-compatible /v1/audio/transcriptions servers such as faster-whisper-server.
+type WhisperServer struct {
+	URL      string
+	Model    string
+	Language string       // ISO 639-1 code; empty (or "auto") leaves the field out for detection
+	Client   *http.Client `json:"-"`
+}
+
+func (t *WhisperServer) Transcribe(ctx context.Context, audioFile string) ([]Segment, error) {
+	// whisper.cpp only accepts 16 kHz wav unless started with --convert
+	if !strings.EqualFold(filepath.Ext(audioFile), ".wav") {
+		wavFile, err := ExtractWav(ctx, audioFile)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(wavFile)
+		audioFile = wavFile
+	}
+
+	body, contentType, err := t.multipartBody(audioFile)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("whisper server error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read error: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("whisper server returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var parsed whisperJSON
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid whisper server response: %w", err)
+	}
+	return parsed.toSegments(), nil
+}
+
+func (t *WhisperServer) multipartBody(audioFile string) (io.Reader, string, error) {
+	f, err := os.Open(audioFile)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", filepath.Base(audioFile))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return nil, "", err
+	}
+
+	fields := map[string]string{"response_format": "verbose_json"}
+	// OpenAI-compatible servers reject "auto"; without the field they detect the language
+	if t.Language != "" && t.Language != AutoLanguage.Code {
+		fields["language"] = t.Language
+	}
+	if t.Model != "" {
+		fields["model"] = t.Model
+	}
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+// ExtractWav converts any media file to 16 kHz mono wav for Speech-to-Text
+func ExtractWav(ctx context.Context, inputFile string) (string, error) {
+	out, err := os.CreateTemp("", "stt_*.wav")
+	if err != nil {
+		return "", err
+	}
+	out.Close()
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-v", "error",
+		"-i", inputFile,
+		"-vn",
+		"-ar", "16000",
+		"-ac", "1",
+		"-c:a", "pcm_s16le",
+		out.Name(),
+	)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("ffmpeg error: %w", err)
+	}
+	return out.Name(), nil
+}
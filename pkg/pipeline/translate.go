@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TranslateErrorPolicy decides what happens to segments whose chunk still fails after retries
+type TranslateErrorPolicy string
+
+const (
+	OnErrorFail       TranslateErrorPolicy = "fail"        // the translation fails with *TranslationError
+	OnErrorSkip       TranslateErrorPolicy = "skip"        // failed segments are left out (no subtitle, silence in the dub)
+	OnErrorKeepSource TranslateErrorPolicy = "keep-source" // failed segments keep the source text
+)
+
+// ParseTranslateErrorPolicy validates a policy name
+func ParseTranslateErrorPolicy(name string) (TranslateErrorPolicy, error) {
+	switch policy := TranslateErrorPolicy(name); policy {
+	case OnErrorFail, OnErrorSkip, OnErrorKeepSource:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown translate error policy %q (want fail, skip or keep-source)", name)
+}
+
+// TranslateOptions controls retries and failure handling of TranslateSegments
+type TranslateOptions struct {
+	Retries int                  // extra attempts per chunk, with exponential backoff
+	OnError TranslateErrorPolicy // default OnErrorFail
+	Video   VideoSummary         // shown to context-aware translators (see SegmentTranslator)
+
+	// SentenceEnd is the source language's sentence punctuation (Language.SentenceEnd),
+	// where segments over the budget are split first; empty uses common terminators
+	SentenceEnd string
+}
+
+// DefaultTranslateOptions retries each chunk three times and then fails
+var DefaultTranslateOptions = TranslateOptions{Retries: 3, OnError: OnErrorFail}
+
+const translateMaxBackoff = 30 * time.Second
+
+// ChunkError is one chunk that could not be translated
+type ChunkError struct {
+	Chunk      int   // 1-based chunk number
+	SegmentIDs []int // segments in the chunk
+	Err        error
+}
+
+func (e ChunkError) Error() string {
+	return fmt.Sprintf("chunk %d (segments %s): %v", e.Chunk, formatIDRanges(e.SegmentIDs), e.Err)
+}
+
+// TranslationError reports every chunk that failed after retries
+type TranslationError struct {
+	Chunks int // total number of chunks
+	Failed []ChunkError
+}
+
+func (e *TranslationError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%d of %d translation chunks failed: %s", len(e.Failed), e.Chunks, strings.Join(msgs, "; "))
+}
+
+func (e *TranslationError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// TranslationStats says how much of a translation is missing or still in the source language
+type TranslationStats struct {
+	Segments          int
+	Untranslated      int // segments skipped or kept in the source language
+	Runes             int // source text size
+	UntranslatedRunes int
+}
+
+// Percent is the untranslated share of the source text
+func (s TranslationStats) Percent() float64 {
+	if s.Runes == 0 {
+		return 0
+	}
+	return float64(s.UntranslatedRunes) / float64(s.Runes) * 100
+}
+
+func (s TranslationStats) String() string {
+	return fmt.Sprintf("%d/%d segments untranslated (%.1f%% of the text)", s.Untranslated, s.Segments, s.Percent())
+}
+
+// CountUntranslated compares source segments with their translation: segments missing
+// from translated (skipped) or marked Untranslated (kept in the source language) count
+func CountUntranslated(source, translated []Segment) TranslationStats {
+	done := make(map[int]bool, len(translated))
+	for _, s := range translated {
+		done[s.ID] = !s.Untranslated
+	}
+
+	stats := TranslationStats{Segments: len(source)}
+	for _, s := range source {
+		n := utf8.RuneCountInString(s.Text)
+		stats.Runes += n
+		if !done[s.ID] {
+			stats.Untranslated++
+			stats.UntranslatedRunes += n
+		}
+	}
+	return stats
+}
+
+// TranslateSegments translates segment by segment so timestamps carry over to the result.
+// Segments are sent in chunks sized by the translator's budget (see Budgeted) and matched
+// back by Segment.ID. A failing chunk is retried with exponential backoff; if it still
+// fails, opts.OnError decides between a *TranslationError and a partial result.
+// A SegmentTranslator gets whole segments with their neighbours instead of texts.
+// Chunk progress is written to progress when it is not nil.
+func TranslateSegments(ctx context.Context, translator Translator, segments []Segment, opts TranslateOptions, progress io.Writer) ([]Segment, error) {
+	if progress == nil {
+		progress = io.Discard
+	}
+	if opts.OnError == "" {
+		opts.OnError = OnErrorFail
+	}
+
+	segments = withSegmentIDs(segments)
+	pieces := make(map[int][]string, len(segments)) // translated pieces by segment ID
+	failedIDs := map[int]bool{}
+	var failed []ChunkError
+
+	chunks := buildChunks(segments, budgetOf(translator), opts.SentenceEnd)
+	translate := func(chunk []chunkPiece) ([]string, error) {
+		texts := make([]string, len(chunk))
+		for j, p := range chunk {
+			texts[j] = p.Text
+		}
+		return translator.Translate(ctx, texts)
+	}
+	if st, ok := windowTranslator(translator); ok {
+		chunks = buildSegmentChunks(segments, budgetOf(translator))
+		translate = func(chunk []chunkPiece) ([]string, error) {
+			return translateWindow(ctx, st, segments, chunk, pieces, opts.Video)
+		}
+	}
+
+	for i, chunk := range chunks {
+		fmt.Fprintf(progress, "  Translating chunk %d/%d...\n", i+1, len(chunks))
+
+		results, err := translateWithRetry(ctx, func() ([]string, error) {
+			results, err := translate(chunk)
+			if err == nil && len(results) != len(chunk) {
+				err = countError(len(results), len(chunk)) // treat the chunk as failed
+			}
+			return results, err
+		}, opts.Retries, progress)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			fmt.Fprintf(progress, "  ❌ Chunk %d/%d failed: %v\n", i+1, len(chunks), err)
+			ce := ChunkError{Chunk: i + 1, Err: err}
+			for _, p := range chunk {
+				if !failedIDs[p.ID] {
+					failedIDs[p.ID] = true
+					ce.SegmentIDs = append(ce.SegmentIDs, p.ID)
+				}
+			}
+			failed = append(failed, ce)
+			continue
+		}
+
+		for j, p := range chunk {
+			pieces[p.ID] = append(pieces[p.ID], strings.TrimSpace(results[j]))
+		}
+	}
+
+	if len(failed) > 0 && opts.OnError == OnErrorFail {
+		return nil, &TranslationError{Chunks: len(chunks), Failed: failed}
+	}
+
+	translated := make([]Segment, 0, len(segments))
+	for _, s := range segments {
+		switch {
+		case !failedIDs[s.ID]:
+			s.Text = strings.Join(pieces[s.ID], " ")
+		case opts.OnError == OnErrorSkip:
+			continue
+		default: // keep-source
+			s.Untranslated = true
+		}
+		translated = append(translated, s)
+	}
+	return translated, nil
+}
+
+// countError reports a translator that answered a different number of texts than it
+// was sent; its answers cannot be matched to the texts
+func countError(got, want int) error {
+	return fmt.Errorf("got %d translations for %d texts", got, want)
+}
+
+// translateWithRetry calls translate, retrying failures after 1s, 2s, 4s, ...
+func translateWithRetry(ctx context.Context, translate func() ([]string, error), retries int, progress io.Writer) ([]string, error) {
+	for attempt := 0; ; attempt++ {
+		results, err := translate()
+		if err == nil {
+			return results, nil
+		}
+		if ctx.Err() != nil || attempt >= retries {
+			return nil, err
+		}
+
+		delay := min(time.Second<<attempt, translateMaxBackoff)
+		fmt.Fprintf(progress, "  🔁 Retry %d/%d in %s: %v\n", attempt+1, retries, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// withSegmentIDs numbers segments 1..n unless every segment already has a unique ID
+// (segments saved before IDs existed have none)
+func withSegmentIDs(segments []Segment) []Segment {
+	seen := make(map[int]bool, len(segments))
+	for _, s := range segments {
+		if s.ID == 0 || seen[s.ID] {
+			numbered := make([]Segment, len(segments))
+			for i, s := range segments {
+				s.ID = i + 1
+				numbered[i] = s
+			}
+			return numbered
+		}
+		seen[s.ID] = true
+	}
+	return segments
+}
+
+// formatIDRanges formats sorted IDs compactly, e.g. "3-7, 12"
+func formatIDRanges(ids []int) string {
+	var parts []string
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		} else {
+			parts = append(parts, fmt.Sprint(ids[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// miscountingTranslator answers extra texts more (or, if negative, fewer) than it is sent
+type miscountingTranslator struct{ extra int }
+
+func (t miscountingTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	if t.extra < 0 {
+		return texts[:len(texts)+t.extra], nil
+	}
+	return append(texts, make([]string, t.extra)...), nil
+}
+
+func TestTranslateSegmentsMiscounted(t *testing.T) {
+	segments := []Segment{{Start: 0, End: 1e9, Text: "Hello."}, {Start: 1e9, End: 2e9, Text: "Bye."}}
+
+	memory, err := OpenTranslationMemory(filepath.Join(t.TempDir(), "memory.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer memory.Close()
+	glossary := &Glossary{Terms: []GlossaryTerm{{Source: "Hello", Target: "မင်္ဂလာပါ"}}}
+
+	wrappers := []struct {
+		name string
+		wrap func(Translator) Translator
+	}{
+		{"bare", func(t Translator) Translator { return t }},
+		{"memory", func(t Translator) Translator {
+			return &MemoryTranslator{Translator: t, Memory: memory, SourceLang: "en", TargetLang: "my"}
+		}},
+		{"glossary", func(t Translator) Translator { return &GlossaryTranslator{Translator: t, Glossary: glossary} }},
+		{"glossary and memory", func(t Translator) Translator {
+			return &GlossaryTranslator{Translator: &MemoryTranslator{Translator: t, Memory: memory, SourceLang: "en", TargetLang: "my"}, Glossary: glossary}
+		}},
+	}
+	for _, w := range wrappers {
+		for _, extra := range []int{-1, 1} {
+			translator := w.wrap(miscountingTranslator{extra})
+
+			got, err := TranslateSegments(context.Background(), translator, segments, TranslateOptions{OnError: OnErrorKeepSource}, nil)
+			if err != nil {
+				t.Fatalf("%s, %+d: %v", w.name, extra, err)
+			}
+			for _, s := range got {
+				if !s.Untranslated {
+					t.Errorf("%s, %+d: segment %d: want untranslated, got %q", w.name, extra, s.ID, s.Text)
+				}
+			}
+
+			_, err = TranslateSegments(context.Background(), translator, segments, TranslateOptions{}, nil)
+			var te *TranslationError
+			if !errors.As(err, &te) {
+				t.Errorf("%s, %+d: want *TranslationError, got %v", w.name, extra, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Translator translates a batch of texts, returning exactly one result per input text
+type Translator interface {
+	Translate(ctx context.Context, texts []string) ([]string, error)
+}
+
+// DeepTranslator uses deep-translator's GoogleTranslator through a Python subprocess (အခမဲ့)
+type DeepTranslator struct {
+	PythonPath string
+	Source     string
+	Target     string
+}
+
+func (t *DeepTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, t.PythonPath, "-c", `
+import sys, json
+from deep_translator import GoogleTranslator
+translator = GoogleTranslator(source=sys.argv[1], target=sys.argv[2])
+texts = json.load(sys.stdin)
+result = translator.translate_batch(texts)
+print(json.dumps(result, ensure_ascii=False))
+`, t.Source, t.Target)
+
+	// Stream stderr to show progress in real-time
+	cmd.Stderr = os.Stderr
+
+	input, err := json.Marshal(texts)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin = bytes.NewReader(input)
+
+	// Capture stdout for the result
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("translation failed: %w", err)
+	}
+
+	var results []string
+	if err := json.Unmarshal(output, &results); err != nil {
+		return nil, fmt.Errorf("invalid translator output: %w", err)
+	}
+	return checkTranslationCount(results, texts)
+}
+
+// OpenAITranslator asks an OpenAI-compatible chat-completions server
+// (llama.cpp server, Ollama, vLLM, ...) to translate a JSON array of texts
+type OpenAITranslator struct {
+	BaseURL string
+	Model   string
+	APIKey  string `json:"-"` // kept out of the manifest
+	Source  string // language names, e.g. "English"; empty source = detect it
+	Target  string
+	Client  *http.Client `json:"-"`
+}
+
+// Budget counts estimated tokens; the reply is about as long as the request,
+// so requests stay small enough for the model's context and output limits
+func (t *OpenAITranslator) Budget() TextBudget {
+	return TextBudget{Max: 1500, Count: EstimateTokens}
+}
+
+func (t *OpenAITranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	results, err := t.translateBatch(ctx, texts)
+	if err == nil || len(texts) == 1 {
+		return results, err
+	}
+
+	// Models sometimes merge or split lines; fall back to one request per text
+	results = make([]string, len(texts))
+	for i, text := range texts {
+		single, err := t.translateBatch(ctx, []string{text})
+		if err != nil {
+			return nil, err
+		}
+		results[i] = single[0]
+	}
+	return results, nil
+}
+
+func (t *OpenAITranslator) translateBatch(ctx context.Context, texts []string) ([]string, error) {
+	input, err := json.Marshal(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	source := "string"
+	if t.Source != "" {
+		source = t.Source + " string"
+	}
+	prompt := fmt.Sprintf("You are a professional translator. Translate each %s in the JSON array into %s. "+
+		"Reply with only a JSON array of strings with exactly %d items, in the same order. "+
+		"Keep product names, code and numbers unchanged.", source, t.Target, len(texts))
+	content, err := chatCompletion(ctx, t.Client, t.BaseURL, t.APIKey, chatRequest{
+		Model: t.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: string(input)},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []string
+	if err := json.Unmarshal([]byte(extractJSON(content)), &results); err != nil {
+		return nil, fmt.Errorf("invalid translation reply: %w", err)
+	}
+	return checkTranslationCount(results, texts)
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// responseFormat asks for structured output matching a JSON schema
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string          `json:"name"`
+	Strict bool            `json:"strict"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// chatCompletion posts to {baseURL}/chat/completions and returns the first choice's content
+func chatCompletion(ctx context.Context, client *http.Client, baseURL, apiKey string, request chatRequest) (string, error) {
+	var resp chatResponse
+	if err := postJSON(ctx, client, strings.TrimSuffix(baseURL, "/")+"/chat/completions", apiKey, request, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("chat completion returned no choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// extractJSON strips Markdown code fences that chat models like to add
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(content, "```")
+	}
+	return strings.TrimSpace(content)
+}
+
+// LibreTranslator calls a LibreTranslate server's /translate endpoint
+type LibreTranslator struct {
+	BaseURL string
+	APIKey  string `json:"-"` // kept out of the manifest
+	Source  string
+	Target  string
+	Client  *http.Client `json:"-"`
+}
+
+func (t *LibreTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	request := map[string]any{
+		"q":      texts,
+		"source": t.Source,
+		"target": t.Target,
+		"format": "text",
+	}
+	if t.APIKey != "" {
+		request["api_key"] = t.APIKey
+	}
+
+	var resp struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	if err := postJSON(ctx, t.Client, strings.TrimSuffix(t.BaseURL, "/")+"/translate", "", request, &resp); err != nil {
+		return nil, err
+	}
+	return checkTranslationCount(resp.TranslatedText, texts)
+}
+
+// postJSON sends a JSON request and decodes the JSON response
+func postJSON(ctx context.Context, client *http.Client, url, bearer string, request, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read error: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{URL: url, Status: resp.Status, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// HTTPError is a non-200 response from a backend server
+type HTTPError struct {
+	URL        string
+	Status     string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s returned %s: %s", e.URL, e.Status, e.Body)
+}
+
+func checkTranslationCount(results, texts []string) ([]string, error) {
+	if len(results) != len(texts) {
+		return nil, fmt.Errorf("translator returned %d results for %d texts", len(results), len(texts))
+	}
+	return results, nil
+}
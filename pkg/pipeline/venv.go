@@ -0,0 +1,25 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// VenvBin finds an executable installed in the project's .venv (whisper, python3, edge-tts)
+func VenvBin(name string) string {
+	path := filepath.Join(filepath.Dir(os.Args[0]), "..", ".venv", "bin", name)
+	// If running with go run, use current working directory
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		path = filepath.Join(projectDir(), ".venv", "bin", name)
+	}
+	return path
+}
+
+// projectDir returns the current working directory
+func projectDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	return dir
+}
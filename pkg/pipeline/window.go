@@ -0,0 +1,295 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// VideoSummary is what a context-aware translator is told about the whole video
+type VideoSummary struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// maxDescriptionRunes keeps long YouTube descriptions (links, chapters) out of every request
+const maxDescriptionRunes = 1000
+
+func (v VideoSummary) short() *VideoSummary {
+	if v.Title == "" && v.Description == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(v.Description) > maxDescriptionRunes {
+		v.Description = string([]rune(v.Description)[:maxDescriptionRunes]) + "…"
+	}
+	return &v
+}
+
+// SegmentWindow is a chunk of segments to translate together with the segments
+// around it. Translated holds the translations already made for Before.
+type SegmentWindow struct {
+	Video      VideoSummary
+	Before     []Segment
+	Segments   []Segment
+	After      []Segment
+	Translated map[int]string
+}
+
+// SegmentTranslator is a Translator that can translate whole timed segments with their
+// neighbours as context, answering by Segment.ID. TranslateSegments uses it when the
+// translator, or the one wrapped by GlossaryTranslator and MemoryTranslator, has it.
+type SegmentTranslator interface {
+	Translator
+	ContextSize() int // neighbouring segments to show on each side
+	TranslateWindow(ctx context.Context, window SegmentWindow) (map[int]string, error)
+}
+
+// windowTranslator returns t as a SegmentTranslator if the translator it wraps is one
+func windowTranslator(t Translator) (SegmentTranslator, bool) {
+	inner := t
+	for {
+		switch w := inner.(type) {
+		case *GlossaryTranslator:
+			inner = w.Translator
+			continue
+		case *MemoryTranslator:
+			inner = w.Translator
+			continue
+		}
+		break
+	}
+	if _, ok := inner.(SegmentTranslator); !ok {
+		return nil, false
+	}
+	st, ok := t.(SegmentTranslator)
+	return st, ok
+}
+
+// errNoWindows is returned when a wrapper is asked for windows its translator cannot translate
+func errNoWindows(t Translator) error {
+	return fmt.Errorf("%s cannot translate segment windows", BackendName(t))
+}
+
+// translateWindow translates a chunk of whole segments with its neighbours and returns
+// the results in chunk order
+func translateWindow(ctx context.Context, st SegmentTranslator, segments []Segment, chunk []chunkPiece, pieces map[int][]string, video VideoSummary) ([]string, error) {
+	first, last := -1, -1
+	for i, s := range segments {
+		if s.ID == chunk[0].ID {
+			first = i
+		}
+		if s.ID == chunk[len(chunk)-1].ID {
+			last = i
+		}
+	}
+	n := st.ContextSize()
+	window := SegmentWindow{
+		Video:      video,
+		Before:     segments[max(first-n, 0):first],
+		Segments:   segments[first : last+1],
+		After:      segments[last+1 : min(last+1+n, len(segments))],
+		Translated: map[int]string{},
+	}
+	for _, s := range window.Before {
+		if p, ok := pieces[s.ID]; ok {
+			window.Translated[s.ID] = strings.Join(p, " ")
+		}
+	}
+
+	byID, err := st.TranslateWindow(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]string, len(chunk))
+	for i, p := range chunk {
+		results[i] = byID[p.ID]
+	}
+	return results, nil
+}
+
+// ContextTranslator asks an OpenAI-compatible chat-completions server to translate
+// timed segments together with the segments around them and the video's title and
+// description, so names, pronouns and terms stay consistent from chunk to chunk.
+// The reply is JSON keyed by segment ID; servers that reject a JSON schema
+// (response_format) are asked again without one.
+type ContextTranslator struct {
+	BaseURL string
+	Model   string
+	APIKey  string `json:"-"` // kept out of the manifest
+	Source  string // language names, e.g. "English"; empty source = detect it
+	Target  string
+	Window  int          // neighbouring segments shown on each side
+	Client  *http.Client `json:"-"`
+
+	noSchema atomic.Bool // the server rejected response_format
+}
+
+// Budget counts estimated tokens of the segments to translate; the context comes on top
+func (t *ContextTranslator) Budget() TextBudget {
+	return TextBudget{Max: 1200, Count: EstimateTokens}
+}
+
+func (t *ContextTranslator) ContextSize() int {
+	return t.Window
+}
+
+// Translate translates texts without timing, e.g. for live mode, as consecutive segments
+func (t *ContextTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	window := SegmentWindow{Segments: make([]Segment, len(texts))}
+	for i, text := range texts {
+		window.Segments[i] = Segment{ID: i + 1, Text: text}
+	}
+	byID, err := t.TranslateWindow(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]string, len(texts))
+	for i := range texts {
+		results[i] = byID[i+1]
+	}
+	return results, nil
+}
+
+// contextRequest is the user message: the video, the context and the segments to translate
+type contextRequest struct {
+	Video    *VideoSummary    `json:"video,omitempty"`
+	Before   []contextSegment `json:"context_before,omitempty"`
+	Segments []contextSegment `json:"segments"`
+	After    []contextSegment `json:"context_after,omitempty"`
+}
+
+type contextSegment struct {
+	ID          int    `json:"id"`
+	Start       string `json:"start,omitempty"`
+	End         string `json:"end,omitempty"`
+	Text        string `json:"text"`
+	Translation string `json:"translation,omitempty"`
+}
+
+// contextReply is the structured output the model must produce
+type contextReply struct {
+	Translations []struct {
+		ID   int    `json:"id"`
+		Text string `json:"text"`
+	} `json:"translations"`
+}
+
+var contextReplySchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "translations": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {"id": {"type": "integer"}, "text": {"type": "string"}},
+        "required": ["id", "text"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["translations"],
+  "additionalProperties": false
+}`)
+
+func toContextSegments(segments []Segment, translated map[int]string) []contextSegment {
+	out := make([]contextSegment, len(segments))
+	for i, s := range segments {
+		out[i] = contextSegment{ID: s.ID, Text: strings.TrimSpace(s.Text), Translation: translated[s.ID]}
+		if s.End > 0 {
+			out[i].Start, out[i].End = formatSRTTimestamp(s.Start), formatSRTTimestamp(s.End)
+		}
+	}
+	return out
+}
+
+func (t *ContextTranslator) TranslateWindow(ctx context.Context, window SegmentWindow) (map[int]string, error) {
+	input, err := json.Marshal(contextRequest{
+		Video:    window.Video.short(),
+		Before:   toContextSegments(window.Before, window.Translated),
+		Segments: toContextSegments(window.Segments, nil),
+		After:    toContextSegments(window.After, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	source := "a video"
+	if t.Source != "" {
+		source = "a video in " + t.Source
+	}
+	prompt := fmt.Sprintf("You are a professional subtitle translator. Translate the subtitle segments of %s into %s. "+
+		"Use the video title and description and the surrounding segments (context_before, with earlier translations, "+
+		"and context_after) to keep names, pronouns, tone and terms consistent, but only translate the items in \"segments\". "+
+		"Translate each segment on its own: never merge, split or reorder segments. "+
+		`Reply with only a JSON object {"translations": [{"id": <segment id>, "text": "<translation>"}]} `+
+		"with exactly one item for each of the %d segments. Keep product names, code, numbers and placeholders like ⟦1⟧ unchanged.",
+		source, t.Target, len(window.Segments))
+	request := chatRequest{
+		Model: t.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: string(input)},
+		},
+		Temperature: 0.2,
+	}
+
+	var content string
+	if !t.noSchema.Load() {
+		request.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "translations", Strict: true, Schema: contextReplySchema}}
+		content, err = chatCompletion(ctx, t.Client, t.BaseURL, t.APIKey, request)
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusBadRequest || httpErr.StatusCode == http.StatusUnprocessableEntity) {
+			t.noSchema.Store(true)
+		}
+	}
+	if t.noSchema.Load() {
+		request.ResponseFormat = nil
+		content, err = chatCompletion(ctx, t.Client, t.BaseURL, t.APIKey, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseContextReply(content, window.Segments)
+}
+
+// parseContextReply maps the reply back to segment IDs, requiring exactly one
+// translation for each segment
+func parseContextReply(content string, segments []Segment) (map[int]string, error) {
+	var reply contextReply
+	if err := json.Unmarshal([]byte(extractJSON(content)), &reply); err != nil {
+		return nil, fmt.Errorf("invalid translation reply: %w", err)
+	}
+
+	wanted := make(map[int]bool, len(segments))
+	for _, s := range segments {
+		wanted[s.ID] = true
+	}
+	results := make(map[int]string, len(segments))
+	for _, tr := range reply.Translations {
+		if !wanted[tr.ID] {
+			return nil, fmt.Errorf("translation reply has unknown segment %d", tr.ID)
+		}
+		if _, dup := results[tr.ID]; dup {
+			return nil, fmt.Errorf("translation reply has segment %d twice", tr.ID)
+		}
+		results[tr.ID] = tr.Text
+	}
+
+	var missing []int
+	for id := range wanted {
+		if _, ok := results[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Ints(missing)
+		return nil, fmt.Errorf("translation reply is missing segments %s", formatIDRanges(missing))
+	}
+	return results, nil
+}
@@ -0,0 +1,304 @@
+package subtitle
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field orders written for [V4+ Styles] and [Events]
+var (
+	assStyleFormat = []string{"Name", "Fontname", "Fontsize", "PrimaryColour", "SecondaryColour", "OutlineColour", "BackColour",
+		"Bold", "Italic", "Underline", "StrikeOut", "ScaleX", "ScaleY", "Spacing", "Angle", "BorderStyle", "Outline", "Shadow",
+		"Alignment", "MarginL", "MarginR", "MarginV", "Encoding"}
+	assEventFormat = []string{"Layer", "Start", "End", "Style", "Name", "MarginL", "MarginR", "MarginV", "Effect", "Text"}
+
+	// SSA v4 files without Format lines
+	ssaStyleFormat = []string{"Name", "Fontname", "Fontsize", "PrimaryColour", "SecondaryColour", "TertiaryColour", "BackColour",
+		"Bold", "Italic", "BorderStyle", "Outline", "Shadow", "Alignment", "MarginL", "MarginR", "MarginV", "AlphaLevel", "Encoding"}
+	ssaEventFormat = []string{"Marked", "Start", "End", "Style", "Name", "MarginL", "MarginR", "MarginV", "Effect", "Text"}
+)
+
+// formatASSTime formats a duration as H:MM:SS.cc
+func formatASSTime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := (d + 5*time.Millisecond).Milliseconds() / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, (cs/6000)%60, (cs/100)%60, cs%100)
+}
+
+func parseASS(text string) (*Track, error) {
+	t := &Track{}
+	var section string
+	var ssa bool // [V4 Styles]: legacy alignment numbers
+	var styleFormat, eventFormat []string
+
+	for n, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(line)
+			switch section {
+			case "[script info]", "[events]":
+			case "[v4+ styles]":
+			case "[v4 styles]":
+				ssa = true
+			default:
+				t.Sections = append(t.Sections, Section{Name: line[1 : len(line)-1]})
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, ":")
+		key, value = strings.TrimSpace(key), strings.TrimLeft(value, " ")
+		switch section {
+		case "[script info]":
+			if strings.HasPrefix(line, ";") || strings.HasPrefix(line, "!:") {
+				t.ScriptInfo = append(t.ScriptInfo, Field{Key: line})
+				continue
+			}
+			t.ScriptInfo = append(t.ScriptInfo, Field{Key: key, Value: value})
+
+		case "[v4+ styles]", "[v4 styles]":
+			switch strings.ToLower(key) {
+			case "format":
+				styleFormat = splitFormat(value)
+			case "style":
+				format := styleFormat
+				if format == nil {
+					format = assStyleFormat
+					if ssa {
+						format = ssaStyleFormat
+					}
+				}
+				t.Styles = append(t.Styles, parseASSStyle(assFields(value, format), ssa))
+			}
+
+		case "[events]":
+			lower := strings.ToLower(key)
+			switch lower {
+			case "format":
+				eventFormat = splitFormat(value)
+				continue
+			case "dialogue", "comment":
+			default:
+				continue // Picture, Sound, Movie and Command events are not subtitles
+			}
+			format := eventFormat
+			if format == nil {
+				format = assEventFormat
+				if ssa {
+					format = ssaEventFormat
+				}
+			}
+			cue, err := parseASSEvent(assFields(value, format))
+			if err != nil {
+				t.warnf("line %d: event skipped: %v", n+1, err)
+				continue
+			}
+			cue.Comment = lower == "comment"
+			t.Cues = append(t.Cues, cue)
+
+		case "":
+			t.warnf("line %d: text outside a section skipped: %q", n+1, line)
+
+		default:
+			last := &t.Sections[len(t.Sections)-1]
+			last.Lines = append(last.Lines, raw)
+		}
+	}
+	return t, nil
+}
+
+func splitFormat(value string) []string {
+	fields := strings.Split(value, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// assFields maps a Style or Dialogue value to its Format names, lower-cased.
+// The last field (Text) may contain commas.
+func assFields(value string, format []string) map[string]string {
+	parts := strings.SplitN(value, ",", len(format))
+	fields := make(map[string]string, len(format))
+	for i, name := range format {
+		if i < len(parts) {
+			v := parts[i]
+			if i < len(format)-1 {
+				v = strings.TrimSpace(v)
+			}
+			fields[strings.ToLower(name)] = v
+		}
+	}
+	return fields
+}
+
+func parseASSStyle(f map[string]string, ssa bool) Style {
+	num := func(key string) float64 {
+		v, _ := strconv.ParseFloat(f[key], 64)
+		return v
+	}
+	integer := func(key string) int {
+		return int(num(key))
+	}
+	flag := func(key string) bool {
+		return num(key) != 0
+	}
+
+	s := Style{
+		Name:            f["name"],
+		FontName:        f["fontname"],
+		FontSize:        num("fontsize"),
+		PrimaryColour:   assColour(f["primarycolour"]),
+		SecondaryColour: assColour(f["secondarycolour"]),
+		OutlineColour:   assColour(f["outlinecolour"]),
+		BackColour:      assColour(f["backcolour"]),
+		Bold:            flag("bold"),
+		Italic:          flag("italic"),
+		Underline:       flag("underline"),
+		StrikeOut:       flag("strikeout"),
+		ScaleX:          100,
+		ScaleY:          100,
+		Spacing:         num("spacing"),
+		Angle:           num("angle"),
+		BorderStyle:     integer("borderstyle"),
+		Outline:         num("outline"),
+		Shadow:          num("shadow"),
+		Alignment:       integer("alignment"),
+		MarginL:         integer("marginl"),
+		MarginR:         integer("marginr"),
+		MarginV:         integer("marginv"),
+		Encoding:        integer("encoding"),
+	}
+	if _, ok := f["scalex"]; ok {
+		s.ScaleX, s.ScaleY = num("scalex"), num("scaley")
+	}
+	if ssa {
+		s.OutlineColour = assColour(f["tertiarycolour"])
+		// SSA alignment: 1-3 bottom, 5-7 top, 9-11 middle; ASS uses the numpad
+		switch a := s.Alignment; {
+		case a >= 9:
+			s.Alignment = a - 5
+		case a >= 5:
+			s.Alignment = a + 2
+		}
+	}
+	return s
+}
+
+// assColour keeps &H colours and converts SSA's decimal ones
+func assColour(v string) string {
+	if v == "" || strings.HasPrefix(strings.ToUpper(v), "&H") {
+		return v
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return v
+	}
+	return fmt.Sprintf("&H%08X", uint32(n))
+}
+
+func parseASSEvent(f map[string]string) (Cue, error) {
+	start, err := parseTimestamp(f["start"])
+	if err != nil {
+		return Cue{}, err
+	}
+	end, err := parseTimestamp(f["end"])
+	if err != nil {
+		return Cue{}, err
+	}
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(f[key])
+		return n
+	}
+	return Cue{
+		Start:   start,
+		End:     end,
+		Text:    assToMarkup(f["text"]),
+		Style:   strings.TrimPrefix(f["style"], "*"),
+		Actor:   f["name"],
+		Layer:   atoi("layer"),
+		MarginL: atoi("marginl"),
+		MarginR: atoi("marginr"),
+		MarginV: atoi("marginv"),
+		Effect:  f["effect"],
+	}, nil
+}
+
+// formatASS writes an ASS (v4.00+) script
+func (t *Track) formatASS() string {
+	var b strings.Builder
+
+	b.WriteString("[Script Info]\n")
+	info := t.ScriptInfo
+	if len(info) == 0 {
+		info = []Field{{"ScriptType", "v4.00+"}, {"WrapStyle", "0"}, {"ScaledBorderAndShadow", "yes"}, {"PlayResX", "384"}, {"PlayResY", "288"}}
+	}
+	for _, f := range info {
+		switch {
+		case strings.EqualFold(f.Key, "ScriptType"):
+			b.WriteString("ScriptType: v4.00+\n")
+		case f.Value == "" && (strings.HasPrefix(f.Key, ";") || strings.HasPrefix(f.Key, "!:")):
+			b.WriteString(f.Key + "\n")
+		default:
+			fmt.Fprintf(&b, "%s: %s\n", f.Key, f.Value)
+		}
+	}
+
+	b.WriteString("\n[V4+ Styles]\nFormat: " + strings.Join(assStyleFormat, ", ") + "\n")
+	styles := t.Styles
+	if len(styles) == 0 {
+		styles = []Style{DefaultStyle}
+	}
+	for _, s := range styles {
+		fmt.Fprintf(&b, "Style: %s,%s,%s,%s,%s,%s,%s,%d,%d,%d,%d,%s,%s,%s,%s,%d,%s,%s,%d,%d,%d,%d,%d\n",
+			s.Name, s.FontName, formatNum(s.FontSize), s.PrimaryColour, s.SecondaryColour, s.OutlineColour, s.BackColour,
+			assBool(s.Bold), assBool(s.Italic), assBool(s.Underline), assBool(s.StrikeOut),
+			formatNum(s.ScaleX), formatNum(s.ScaleY), formatNum(s.Spacing), formatNum(s.Angle),
+			s.BorderStyle, formatNum(s.Outline), formatNum(s.Shadow), s.Alignment, s.MarginL, s.MarginR, s.MarginV, s.Encoding)
+	}
+
+	b.WriteString("\n[Events]\nFormat: " + strings.Join(assEventFormat, ", ") + "\n")
+	for _, c := range t.Cues {
+		kind := "Dialogue"
+		if c.Comment {
+			kind = "Comment"
+		}
+		style := c.Style
+		if style == "" {
+			style = styles[0].Name
+		}
+		fmt.Fprintf(&b, "%s: %d,%s,%s,%s,%s,%d,%d,%d,%s,%s\n", kind, c.Layer, formatASSTime(c.Start), formatASSTime(c.End),
+			style, c.Actor, c.MarginL, c.MarginR, c.MarginV, c.Effect, markupToASS(c.Text))
+	}
+
+	for _, s := range t.Sections {
+		fmt.Fprintf(&b, "\n[%s]\n", s.Name)
+		for _, line := range s.Lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func formatNum(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.Itoa(int(v))
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// assBool is -1 for true, as ASS writes it
+func assBool(v bool) int {
+	if v {
+		return -1
+	}
+	return 0
+}
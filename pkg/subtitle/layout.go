@@ -0,0 +1,420 @@
+package subtitle
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// WidthUnit is what Layout.MaxLineWidth counts
+type WidthUnit string
+
+const (
+	Chars  WidthUnit = "chars" // visible characters (Characters)
+	Pixels WidthUnit = "px"    // estimated rendered width at Layout.FontSize
+)
+
+// ParseWidthUnit accepts "chars" or "px"
+func ParseWidthUnit(name string) (WidthUnit, error) {
+	switch strings.ToLower(name) {
+	case "chars", "char", "characters":
+		return Chars, nil
+	case "px", "pixels":
+		return Pixels, nil
+	}
+	return "", fmt.Errorf("unknown width unit %q (want chars or px)", name)
+}
+
+// Layout fits cues to what a viewer can read: at most MaxLines lines of at most
+// MaxLineWidth each, shown long enough to read at MaxCPS characters per second.
+// Lines break between words, and between syllables in Burmese, never inside one.
+type Layout struct {
+	MaxLineWidth int
+	Unit         WidthUnit
+	FontSize     float64 // for Pixels, in the renderer's units (libass scales SRT to a 384x288 frame)
+	MaxLines     int
+	MaxCPS       float64       // 0 disables the reading speed limit
+	MinDuration  time.Duration // shortest time a cue stays on screen
+	MinGap       time.Duration // left free before the next cue when a cue is extended
+}
+
+// DefaultLayout is two lines of 42 characters at 17 characters per second
+var DefaultLayout = Layout{
+	MaxLineWidth: 42,
+	Unit:         Chars,
+	FontSize:     24,
+	MaxLines:     2,
+	MaxCPS:       17,
+	MinDuration:  time.Second,
+	MinGap:       80 * time.Millisecond,
+}
+
+// LayoutReport counts what Apply changed
+type LayoutReport struct {
+	Split    int // cues too long for MaxLines lines, split in several
+	Merged   int // cues merged with a neighbour to slow them down
+	Extended int // cues kept on screen longer, into the silence after them
+	TooFast  int // cues still faster than MaxCPS or shorter than MinDuration
+}
+
+// Apply lays out cues (in time order) and returns the new cues. Markup is dropped and
+// the text re-wrapped, escaped so "Vec<T>" stays text. Timing stays within the source:
+// split cues share their cue's time in proportion to their length, and a cue only
+// grows into the silence before the next one, never past the end of the last. ASS comments are kept after the other cues.
+func (l Layout) Apply(cues []Cue) ([]Cue, LayoutReport) {
+	l = l.withDefaults()
+	var report LayoutReport
+	var src, out, comments []Cue
+	var last time.Duration
+	for _, c := range cues {
+		if c.Comment {
+			comments = append(comments, c)
+			continue
+		}
+		last = max(last, c.End)
+		c.Text = strings.Join(strings.Fields(PlainText(c.Text)), " ")
+		src = append(src, c)
+	}
+	for i := range src {
+		// Give the whole cue its reading time first, so its pieces share it
+		if l.extend(src, i, last) {
+			report.Extended++
+		}
+		pieces := l.split(breakUnits(src[i].Text))
+		if len(pieces) > 1 {
+			report.Split++
+		}
+		out = append(out, spreadCue(src[i], pieces)...)
+	}
+
+	for i := 0; i < len(out); {
+		if l.extend(out, i, last) {
+			report.Extended++
+		}
+		if !l.tooFast(out[i]) {
+			i++
+			continue
+		}
+		j := l.mergeTarget(out, i)
+		if j < 0 {
+			i++
+			continue
+		}
+		a := min(i, j)
+		out[a].Text = out[a].Text + " " + out[a+1].Text
+		out[a].End = max(out[a].End, out[a+1].End)
+		out = append(out[:a+1], out[a+2:]...)
+		report.Merged++
+		i = a
+	}
+
+	for i := range out {
+		if l.tooFast(out[i]) {
+			report.TooFast++
+		}
+		lines, _ := l.wrap(breakUnits(out[i].Text))
+		out[i].Text = Escape(strings.Join(lines, "\n"))
+	}
+	return append(out, comments...), report
+}
+
+// Wrap breaks the plain text of markup into balanced lines like Apply, without
+// splitting it: text too long for MaxLines lines gets more lines. The lines are plain
+// text; Escape them to use them as markup.
+func (l Layout) Wrap(text string) []string {
+	lines, _ := l.withDefaults().wrap(breakUnits(strings.Join(strings.Fields(PlainText(text)), " ")))
+	return lines
+}
+
+func (l Layout) withDefaults() Layout {
+	if l.MaxLineWidth <= 0 {
+		l.MaxLineWidth = DefaultLayout.MaxLineWidth
+		if l.Unit == "" {
+			l.Unit = DefaultLayout.Unit
+		}
+	}
+	if l.Unit == "" {
+		l.Unit = Chars
+	}
+	if l.FontSize <= 0 {
+		l.FontSize = DefaultLayout.FontSize
+	}
+	if l.MaxLines <= 0 {
+		l.MaxLines = DefaultLayout.MaxLines
+	}
+	return l
+}
+
+// Width measures text in the layout's unit
+func (l Layout) Width(text string) int {
+	if l.Unit == Pixels {
+		return int(math.Ceil(l.withDefaults().pixels(text)))
+	}
+	return Characters(text)
+}
+
+func (l Layout) pixels(text string) float64 {
+	var em float64
+	for _, r := range text {
+		em += advance(r)
+	}
+	return em * l.FontSize
+}
+
+// advance estimates a character's width in ems: the widths of a typical sans-serif
+// Latin font, and of Noto Sans Myanmar and CJK fonts
+func advance(r rune) float64 {
+	switch {
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), r == '\u200b':
+		return 0
+	case unicode.Is(unicode.Mc, r):
+		return 0.4 // spacing vowel signs and medials: ာ ေ ြ း
+	case isMyanmar(r):
+		return 0.75
+	case isCJK(r) || unicode.Is(unicode.Hangul, r):
+		return 1
+	case r == ' ' || r == '\u00a0':
+		return 0.28
+	case strings.ContainsRune("iIjlft.,;:!|'`()[]", r):
+		return 0.28
+	case strings.ContainsRune("mwMW", r):
+		return 0.85
+	case unicode.IsUpper(r):
+		return 0.68
+	}
+	return 0.55
+}
+
+// tooFast reports whether a cue is shown too briefly to read
+func (l Layout) tooFast(c Cue) bool {
+	d := c.End - c.Start
+	return d < l.readingTime(c.Text)
+}
+
+// readingTime is how long text must stay on screen
+func (l Layout) readingTime(text string) time.Duration {
+	d := l.MinDuration
+	if l.MaxCPS > 0 {
+		d = max(d, time.Duration(float64(Characters(text))/l.MaxCPS*float64(time.Second)))
+	}
+	return d
+}
+
+// extend keeps cue i on screen until it can be read, as far as the next cue (less
+// MinGap) or the end of the source allows
+func (l Layout) extend(cues []Cue, i int, last time.Duration) bool {
+	c := &cues[i]
+	need := c.Start + l.readingTime(c.Text)
+	if c.End >= need {
+		return false
+	}
+	limit := last
+	if i+1 < len(cues) {
+		limit = min(limit, cues[i+1].Start-l.MinGap)
+	}
+	if end := min(need, limit); end > c.End {
+		c.End = end
+		return true
+	}
+	return false
+}
+
+// mergeTarget picks the neighbour (i-1 or i+1) that cue i can share a cue with: same
+// style, fits the lines, and slower to read together than cue i alone. -1 if none.
+func (l Layout) mergeTarget(cues []Cue, i int) int {
+	best, bestCPS := -1, cps(cues[i])
+	for _, j := range []int{i - 1, i + 1} {
+		if j < 0 || j >= len(cues) {
+			continue
+		}
+		a, b := cues[min(i, j)], cues[max(i, j)]
+		if a.Style != b.Style || a.Layer != b.Layer || a.Actor != b.Actor {
+			continue
+		}
+		merged := Cue{Start: a.Start, End: max(a.End, b.End), Text: a.Text + " " + b.Text}
+		if _, ok := l.wrap(breakUnits(merged.Text)); !ok {
+			continue
+		}
+		if v := cps(merged); v < bestCPS {
+			best, bestCPS = j, v
+		}
+	}
+	return best
+}
+
+// cps is a cue's reading speed in characters per second
+func cps(c Cue) float64 {
+	d := (c.End - c.Start).Seconds()
+	if d <= 0 {
+		return math.Inf(1)
+	}
+	return float64(Characters(c.Text)) / d
+}
+
+// spreadCue turns the pieces of a cue's text into cues that share its time in
+// proportion to their length
+func spreadCue(c Cue, pieces []string) []Cue {
+	if len(pieces) == 1 {
+		c.ID, c.Text = "", pieces[0]
+		return []Cue{c}
+	}
+	total := 0
+	for _, p := range pieces {
+		total += max(Characters(p), 1)
+	}
+	cues := make([]Cue, len(pieces))
+	start, done := c.Start, 0
+	for i, p := range pieces {
+		done += max(Characters(p), 1)
+		end := c.Start + time.Duration(float64(c.End-c.Start)*float64(done)/float64(total))
+		if i == len(pieces)-1 {
+			end = c.End
+		}
+		cues[i] = c
+		cues[i].ID, cues[i].Text, cues[i].Start, cues[i].End = "", p, start, end
+		start = end
+	}
+	return cues
+}
+
+// split cuts text (as break units) into the fewest pieces that each fit MaxLines
+// lines, with cuts that even out the pieces and fall after sentences and clauses
+func (l Layout) split(units []string) []string {
+	width := l.lineWidths(units)
+	capacity := l.MaxLineWidth * l.MaxLines
+	cuts := partition(len(units), len(units), func(i, j int) (float64, bool) {
+		if l.lineCount(width, i, j) > l.MaxLines && j-i > 1 {
+			return 0, false
+		}
+		slack := float64(max(capacity-width(i, j), 0))
+		return slack * slack, true
+	}, units)
+
+	var pieces []string
+	for k := 1; k < len(cuts); k++ {
+		pieces = append(pieces, strings.TrimSpace(strings.Join(units[cuts[k-1]:cuts[k]], "")))
+	}
+	return pieces
+}
+
+// lineCount is how many lines units[i:j] need, filling each line in turn (which
+// needs no more lines than any other way)
+func (l Layout) lineCount(width func(i, j int) int, i, j int) int {
+	lines, start := 1, i
+	for e := i + 2; e <= j; e++ {
+		if width(start, e) > l.MaxLineWidth && e-start > 1 {
+			lines++
+			start = e - 1
+		}
+	}
+	return lines
+}
+
+// breakWeight scales the cost of a line ending after unit: ending a sentence or a
+// clause, or at a space, is better than between two Burmese syllables
+func breakWeight(unit string) float64 {
+	switch {
+	case endsWith(unit, sentenceEnds):
+		return 0.5
+	case endsWith(unit, clauseEnds):
+		return 0.7
+	case strings.TrimRightFunc(unit, unicode.IsSpace) != unit:
+		return 0.9
+	}
+	return 1
+}
+
+// wrap breaks units into at most MaxLines balanced lines no wider than MaxLineWidth.
+// A unit wider than a line gets a line of its own. ok is false if the text needs
+// more lines; lines then holds it all anyway.
+func (l Layout) wrap(units []string) (lines []string, ok bool) {
+	if len(units) == 0 {
+		return nil, true
+	}
+	width := l.lineWidths(units)
+	if lines, ok := l.breakLines(units, width, max(l.MaxLines, 1)); ok {
+		return lines, true
+	}
+	lines, _ = l.breakLines(units, width, len(units)) // too long: still wrap it, for the caller to split
+	return lines, false
+}
+
+// lineWidths returns the width of a line of units[i:j]. Widths add up unit by unit;
+// a line does not count its trailing spaces.
+func (l Layout) lineWidths(units []string) func(i, j int) int {
+	prefix := make([]int, len(units)+1)
+	trailing := make([]int, len(units))
+	for i, u := range units {
+		prefix[i+1] = prefix[i] + l.Width(u)
+		trailing[i] = l.Width(u) - l.Width(strings.TrimRightFunc(u, unicode.IsSpace))
+	}
+	return func(i, j int) int { return prefix[j] - prefix[i] - trailing[j-1] }
+}
+
+// breakLines finds the fewest lines, up to maxLines, that hold units, and among those
+// the break points with the least squared slack, so the lines come out balanced
+func (l Layout) breakLines(units []string, width func(i, j int) int, maxLines int) ([]string, bool) {
+	cuts := partition(len(units), maxLines, func(i, j int) (float64, bool) {
+		w := width(i, j)
+		if w > l.MaxLineWidth && j-i > 1 {
+			return 0, false
+		}
+		slack := float64(max(l.MaxLineWidth-w, 0))
+		return slack * slack, true
+	}, units)
+	if cuts == nil {
+		return nil, false
+	}
+	lines := make([]string, 0, len(cuts)-1)
+	for k := 1; k < len(cuts); k++ {
+		lines = append(lines, strings.TrimSpace(strings.Join(units[cuts[k-1]:cuts[k]], "")))
+	}
+	return lines, true
+}
+
+// partition cuts n units into the fewest parts, up to maxParts, that part accepts, and
+// among those the cheapest; a cut after a sentence, clause or space costs less (see
+// breakWeight). part(i, j) rates units[i:j] and must reject longer parts with the
+// same end once it rejects one. It returns the cut points from 0 to n, or nil.
+func partition(n, maxParts int, part func(i, j int) (float64, bool), units []string) []int {
+	inf := math.Inf(1)
+	cost := make([][]float64, maxParts+1) // cost[k][j]: units[:j] in k parts
+	from := make([][]int, maxParts+1)
+	cost[0] = make([]float64, n+1)
+	for j := 1; j <= n; j++ {
+		cost[0][j] = inf
+	}
+	for k := 1; k <= maxParts; k++ {
+		cost[k], from[k] = make([]float64, n+1), make([]int, n+1)
+		cost[k][0] = inf
+		for j := 1; j <= n; j++ {
+			cost[k][j] = inf
+			for i := j - 1; i >= 0; i-- {
+				c, ok := part(i, j)
+				if !ok {
+					break
+				}
+				if cost[k-1][i] == inf {
+					continue
+				}
+				if j < n {
+					c *= breakWeight(units[j-1])
+				}
+				if c += cost[k-1][i]; c < cost[k][j] {
+					cost[k][j], from[k][j] = c, i
+				}
+			}
+		}
+		if cost[k][n] < inf {
+			cuts := make([]int, k+1)
+			cuts[k] = n
+			for kk := k; kk > 0; kk-- {
+				cuts[kk-1] = from[kk][cuts[kk]]
+			}
+			return cuts
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package subtitle
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"မြန်မာ", []string{"မြန်", "မာ"}},
+		{"ကျွန်ုပ်", []string{"ကျွန်ုပ်"}},
+		{"မင်္ဂလာပါ", []string{"မင်္ဂ", "လာ", "ပါ"}},
+		{"ဗုဒ္ဓ", []string{"ဗုဒ္ဓ"}},
+		{"ကျွန်တော် YouTube", []string{"ကျွန်", "တော်", " ", "YouTube"}},
+		{"၁၂၃ ခု", []string{"၁၂၃", " ", "ခု"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Syllables(tt.in); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Syllables(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout Layout
+		in     string
+		want   []string
+	}{
+		{"fits", Layout{MaxLineWidth: 42, MaxLines: 2}, "Short line", []string{"Short line"}},
+		{"balanced", Layout{MaxLineWidth: 30, MaxLines: 2}, "The quick brown fox jumps over the lazy dog",
+			[]string{"The quick brown fox", "jumps over the lazy dog"}},
+		{"Burmese between syllables", Layout{MaxLineWidth: 8, MaxLines: 2}, "မြန်မာနိုင်ငံအကြောင်း",
+			[]string{"မြန်မာနိုင်", "ငံအကြောင်း"}},
+		{"too long gets more lines", Layout{MaxLineWidth: 10, MaxLines: 2}, "one two three four five six",
+			[]string{"one two", "three four", "five six"}},
+		{"markup dropped", Layout{MaxLineWidth: 42, MaxLines: 2}, "<i>Vec&lt;T&gt;</i>", []string{"Vec<T>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.layout.Wrap(tt.in)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Wrap(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartition(t *testing.T) {
+	units := []string{"aa ", "bb ", "cc ", "dd ", "ee"}
+	width := func(i, j int) int { return 3*(j-i) - 1 }
+	tests := []struct {
+		name     string
+		maxWidth int
+		maxParts int
+		want     []int
+	}{
+		{"one part", 20, 3, []int{0, 5}},
+		{"balanced", 9, 3, []int{0, 2, 5}},
+		{"one unit each", 2, 5, []int{0, 1, 2, 3, 4, 5}},
+		{"too few parts", 2, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := partition(len(units), tt.maxParts, func(i, j int) (float64, bool) {
+				w := width(i, j)
+				if w > tt.maxWidth {
+					return 0, false
+				}
+				slack := float64(tt.maxWidth - w)
+				return slack * slack, true
+			}, units)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("partition = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	layout := Layout{MaxLineWidth: 20, Unit: Chars, MaxLines: 2, MaxCPS: 17, MinDuration: time.Second, MinGap: 80 * time.Millisecond}
+	long := "This first sentence is long enough. The second one is just as long, or longer."
+	cues := []Cue{
+		{Start: 0, End: 8 * time.Second, Text: Escape(long)},
+		{Start: 10 * time.Second, End: 10200 * time.Millisecond, Text: "Hi"},
+		{Start: 12 * time.Second, End: 14 * time.Second, Text: "Vec&lt;T&gt; <i>x</i>"},
+	}
+	out, report := layout.Apply(cues)
+
+	if report.Split != 1 || report.Extended != 1 {
+		t.Errorf("report = %+v, want 1 split and 1 extended", report)
+	}
+	var texts []string
+	for i, c := range out {
+		lines := strings.Split(c.Text, "\n")
+		if len(lines) > layout.MaxLines {
+			t.Errorf("cue %d has %d lines: %q", i, len(lines), c.Text)
+		}
+		for _, line := range lines {
+			if w := layout.Width(PlainText(line)); w > layout.MaxLineWidth {
+				t.Errorf("cue %d line %q is %d wide", i, line, w)
+			}
+		}
+		if i > 0 && c.Start < out[i-1].End {
+			t.Errorf("cue %d starts at %v, before cue %d ends at %v", i, c.Start, i-1, out[i-1].End)
+		}
+		texts = append(texts, PlainText(strings.ReplaceAll(c.Text, "\n", " ")))
+	}
+	if got := strings.Join(texts[:len(texts)-2], " "); got != long {
+		t.Errorf("split text = %q, want %q", got, long)
+	}
+	if hi := out[len(out)-2]; hi.End-hi.Start < layout.MinDuration {
+		t.Errorf("short cue lasts %v, want at least %v", hi.End-hi.Start, layout.MinDuration)
+	}
+	if last := out[len(out)-1]; last.Text != "Vec&lt;T&gt; x" || last.End != 14*time.Second {
+		t.Errorf("last cue = %q until %v", last.Text, last.End)
+	}
+}
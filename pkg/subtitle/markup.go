@@ -0,0 +1,183 @@
+package subtitle
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	tagRe      = regexp.MustCompile(`<(/?)([a-zA-Z]+)([.\s][^<>]*)?>|<\d[^<>]*>`) // HTML-like tags and WebVTT timestamps
+	blockRe    = regexp.MustCompile(`\{[^{}]*\}`)                                 // ASS override blocks
+	fontColour = regexp.MustCompile(`(?i)color\s*=\s*["']?#?([0-9a-f]{6})`)
+	alignRe    = regexp.MustCompile(`\\an?\d+`)
+)
+
+// markupTags are the tags cue markup uses; SRT text with other <...> is read as text
+var markupTags = []string{"i", "b", "u", "s", "font", "c", "v", "lang", "ruby", "rt"}
+
+var (
+	escaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "{", "&#123;", "}", "&#125;")
+	unescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&#123;", "{", "&#125;", "}")
+)
+
+// Escape turns plain text into cue markup that shows it as it is
+func Escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// PlainText strips tags and ASS override blocks and resolves escapes, e.g. to measure
+// or speak the text
+func PlainText(text string) string {
+	return unescaper.Replace(blockRe.ReplaceAllString(tagRe.ReplaceAllString(text, ""), ""))
+}
+
+var overrideRe = regexp.MustCompile(`\{\\[^{}]*\}`) // {\an8}, not "{ return x }"
+
+// srtToMarkup reads SRT text: known tags and ASS override blocks are markup, any
+// other <, >, { and } is text, and SRT has no entities, so & is text too
+func srtToMarkup(text string) string {
+	var b strings.Builder
+	last := 0
+	keep := func(start, end int) {
+		if start < last {
+			return
+		}
+		b.WriteString(escaper.Replace(text[last:start]))
+		b.WriteString(text[start:end])
+		last = end
+	}
+	spans := append(tagRe.FindAllStringSubmatchIndex(text, -1), overrideRe.FindAllStringSubmatchIndex(text, -1)...)
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	for _, m := range spans {
+		if len(m) > 2 && m[4] >= 0 {
+			name := text[m[4]:m[5]]
+			known := slices.ContainsFunc(markupTags, func(tag string) bool { return strings.EqualFold(tag, name) })
+			if !known || m[6] >= 0 && !strings.EqualFold(name, "font") {
+				continue // <T>, "<b and c>" or another tag SRT does not have
+			}
+		}
+		keep(m[0], m[1])
+	}
+	b.WriteString(escaper.Replace(text[last:]))
+	return b.String()
+}
+
+// assToMarkup turns ASS event text into cue markup: \N line breaks, and the italic,
+// bold, underline, strike-out and colour overrides into tags. Other overrides stay in
+// {...} blocks and comments are kept.
+func assToMarkup(text string) string {
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `\{`, "&#123;", `\}`, "&#125;",
+		`\N`, "\n", `\n`, "\n", `\h`, "\u00a0").Replace(text)
+	return blockRe.ReplaceAllStringFunc(text, func(block string) string {
+		body := block[1 : len(block)-1]
+		if !strings.Contains(body, `\`) {
+			return block // a comment
+		}
+
+		var tags, rest strings.Builder
+		for _, tag := range strings.Split(body, `\`)[1:] {
+			if markup, ok := assTagToMarkup(tag); ok {
+				tags.WriteString(markup)
+			} else {
+				rest.WriteString(`\` + tag)
+			}
+		}
+		if rest.Len() > 0 {
+			return "{" + rest.String() + "}" + tags.String()
+		}
+		return tags.String()
+	})
+}
+
+var assToggleRe = regexp.MustCompile(`^([ibus])(\d*)$`)
+var assColourRe = regexp.MustCompile(`(?i)^1?c(?:&H([0-9a-f]+)&?)?$`)
+
+func assTagToMarkup(tag string) (string, bool) {
+	if m := assToggleRe.FindStringSubmatch(tag); m != nil {
+		if m[2] == "" || m[2] == "0" {
+			return "</" + m[1] + ">", true
+		}
+		return "<" + m[1] + ">", true
+	}
+	if m := assColourRe.FindStringSubmatch(tag); m != nil {
+		if m[1] == "" {
+			return "</font>", true
+		}
+		bgr := fmt.Sprintf("%06s", m[1])
+		bgr = bgr[len(bgr)-6:]
+		return fmt.Sprintf(`<font color="#%s%s%s">`, bgr[4:6], bgr[2:4], bgr[0:2]), true
+	}
+	return "", false
+}
+
+// markupToASS turns cue markup into ASS event text
+func markupToASS(text string) string {
+	text = tagRe.ReplaceAllStringFunc(text, func(tag string) string {
+		m := tagRe.FindStringSubmatch(tag)
+		closing, name := m[1] == "/", strings.ToLower(m[2])
+		switch name {
+		case "i", "b", "u", "s":
+			if closing {
+				return `{\` + name + `0}`
+			}
+			return `{\` + name + `1}`
+		case "font":
+			if closing {
+				return `{\c}`
+			}
+			if c := fontColour.FindStringSubmatch(m[3]); c != nil {
+				rgb := strings.ToUpper(c[1])
+				return `{\c&H` + rgb[4:6] + rgb[2:4] + rgb[0:2] + `&}`
+			}
+		}
+		return ""
+	})
+	text = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&#123;", `\{`, "&#125;", `\}`).Replace(text)
+	return strings.ReplaceAll(text, "\n", `\N`)
+}
+
+// markupToSRT keeps the tags SRT players understand, and {\anN} position overrides
+func markupToSRT(text string) string {
+	text = keepTags(text, false, "i", "b", "u", "s", "font")
+	text = blockRe.ReplaceAllStringFunc(text, func(block string) string {
+		if an := alignRe.FindString(block); an != "" {
+			return "{" + an + "}"
+		}
+		return ""
+	})
+	return unescaper.Replace(text)
+}
+
+var ampersandRe = regexp.MustCompile(`&(#?[a-zA-Z0-9]+;)?`)
+
+// markupToVTT keeps WebVTT's tags and escapes ampersands that do not start an entity
+func markupToVTT(text string) string {
+	text = keepTags(text, true, "i", "b", "u", "c", "v", "lang", "ruby", "rt")
+	text = blockRe.ReplaceAllString(text, "")
+	text = strings.NewReplacer("&#123;", "{", "&#125;", "}").Replace(text)
+	return ampersandRe.ReplaceAllStringFunc(text, func(amp string) string {
+		if amp == "&" {
+			return "&amp;"
+		}
+		return amp // an entity such as &lt;
+	})
+}
+
+// keepTags removes every tag not named, and WebVTT timestamps unless timestamps is set
+func keepTags(text string, timestamps bool, names ...string) string {
+	return tagRe.ReplaceAllStringFunc(text, func(tag string) string {
+		m := tagRe.FindStringSubmatch(tag)
+		if m[2] == "" && timestamps {
+			return tag
+		}
+		for _, n := range names {
+			if strings.EqualFold(m[2], n) {
+				return tag
+			}
+		}
+		return ""
+	})
+}
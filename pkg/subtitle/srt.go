@@ -0,0 +1,93 @@
+package subtitle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formatSRTTime formats a duration as HH:MM:SS,mmm
+func formatSRTTime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, (ms/60000)%60, (ms/1000)%60, ms%1000)
+}
+
+func parseSRT(text string) (*Track, error) {
+	t := &Track{}
+	t.Cues = parseCueBlocks(strings.Split(text, "\n"), 0, t)
+	for i := range t.Cues {
+		t.Cues[i].Text = srtToMarkup(t.Cues[i].Text)
+	}
+	return t, nil
+}
+
+// parseCueBlocks reads the "identifier, timing line, text lines" blocks that SRT and
+// WebVTT share. It does not need blank lines between cues: a timing line, or a number
+// followed by one, starts the next cue. first is the line number of lines[0] minus one.
+func parseCueBlocks(lines []string, first int, t *Track) []Cue {
+	isTiming := func(i int) bool {
+		if i >= len(lines) {
+			return false
+		}
+		_, _, _, ok, _ := parseTiming(lines[i])
+		return ok
+	}
+
+	var cues []Cue
+	for i := 0; i < len(lines); {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			continue
+		}
+
+		var id string
+		if !isTiming(i) {
+			if !isTiming(i + 1) {
+				t.warnf("line %d: text outside a cue skipped: %q", first+i+1, line)
+				i++
+				continue
+			}
+			id = line
+			i++
+		}
+
+		start, end, settings, _, err := parseTiming(lines[i])
+		timingLine := i
+		i++
+		var text []string
+		for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !isTiming(i) && !(isNumber(lines[i]) && isTiming(i+1)) {
+			text = append(text, strings.TrimRight(lines[i], " \t"))
+			i++
+		}
+		if err != nil {
+			t.warnf("line %d: cue skipped: %v", first+timingLine+1, err)
+			continue
+		}
+		cues = append(cues, Cue{ID: id, Start: start, End: end, Settings: settings, Text: strings.Join(text, "\n")})
+	}
+	return cues
+}
+
+// formatSRT numbers cues from 1 and keeps the SRT subset of the markup
+func (t *Track) formatSRT() string {
+	var b strings.Builder
+	n := 0
+	for _, c := range t.Cues {
+		if c.Comment {
+			continue
+		}
+		n++
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", n, formatSRTTime(c.Start), formatSRTTime(c.End), markupToSRT(c.Text))
+	}
+	return b.String()
+}
+
+func isNumber(line string) bool {
+	_, err := strconv.Atoi(strings.TrimSpace(line))
+	return err == nil
+}
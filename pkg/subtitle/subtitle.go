@@ -0,0 +1,338 @@
+// Package subtitle reads and writes SubRip (.srt), WebVTT (.vtt) and ASS/SSA (.ass, .ssa)
+// subtitles through one Cue/Track model, so tracks can be converted between formats.
+//
+// Cue text uses the HTML-like tags of SRT and WebVTT (<i>, <b>, <u>, <s>,
+// <font color="#rrggbb">, WebVTT's <c>, <v> ...) with "\n" between lines; ASS override
+// blocks the tags cannot express ({\an8}, {\pos(..)}) are kept as they are. Each writer
+// converts the markup to what its format supports. Literal <, >, &, { and } are written
+// as &lt; &gt; &amp; &#123; &#125; (see Escape), so text such as "Vec<T>" or
+// "{ return x }" is never taken for markup.
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Format is a subtitle file format
+type Format string
+
+const (
+	SRT    Format = "srt"
+	WebVTT Format = "vtt"
+	ASS    Format = "ass"
+	SSA    Format = "ssa" // read like ASS; written as ASS (v4.00+)
+)
+
+// ParseFormat accepts a format name or file extension ("srt", ".vtt", "webvtt")
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "srt", "subrip":
+		return SRT, nil
+	case "vtt", "webvtt":
+		return WebVTT, nil
+	case "ass":
+		return ASS, nil
+	case "ssa":
+		return SSA, nil
+	}
+	return "", fmt.Errorf("unknown subtitle format %q (want srt, vtt, ass or ssa)", name)
+}
+
+// FormatOf picks the format from a file's extension
+func FormatOf(file string) (Format, error) {
+	return ParseFormat(filepath.Ext(file))
+}
+
+// Cue is one timed subtitle
+type Cue struct {
+	ID         string // SRT number or WebVTT identifier, if any
+	Start, End time.Duration
+	Text       string // markup as described in the package documentation, lines separated by "\n"
+
+	Settings string // WebVTT cue settings, e.g. "align:start line:0"
+
+	// ASS event fields
+	Style                     string // style name; empty means "Default"
+	Actor                     string
+	Layer                     int
+	MarginL, MarginR, MarginV int
+	Effect                    string
+	Comment                   bool // an ASS Comment: line, not shown and not written to SRT or WebVTT
+}
+
+// PlainText is the cue text without any markup
+func (c Cue) PlainText() string {
+	return PlainText(c.Text)
+}
+
+// Style is an ASS style (the [V4+ Styles] section)
+type Style struct {
+	Name            string
+	FontName        string
+	FontSize        float64
+	PrimaryColour   string // &HAABBGGRR
+	SecondaryColour string
+	OutlineColour   string
+	BackColour      string
+	Bold            bool
+	Italic          bool
+	Underline       bool
+	StrikeOut       bool
+	ScaleX, ScaleY  float64 // percent
+	Spacing         float64
+	Angle           float64
+	BorderStyle     int // 1 outline and shadow, 3 opaque box
+	Outline         float64
+	Shadow          float64
+	Alignment       int // numpad position: 2 bottom centre, 8 top centre
+	MarginL         int
+	MarginR         int
+	MarginV         int
+	Encoding        int
+}
+
+// DefaultStyle is the style ffmpeg gives converted SRT files, for a 384x288 script
+var DefaultStyle = Style{
+	Name: "Default", FontName: "Arial", FontSize: 16,
+	PrimaryColour: "&Hffffff", SecondaryColour: "&Hffffff", OutlineColour: "&H0", BackColour: "&H0",
+	ScaleX: 100, ScaleY: 100, BorderStyle: 1, Outline: 1, Alignment: 2,
+	MarginL: 10, MarginR: 10, MarginV: 10,
+}
+
+// Field is a "Key: value" line of an ASS [Script Info] section
+type Field struct {
+	Key, Value string
+}
+
+// Section is an ASS section kept verbatim, such as [Fonts] or [Graphics]
+type Section struct {
+	Name  string
+	Lines []string
+}
+
+// Track is a subtitle file: its cues plus what each format needs to round-trip
+type Track struct {
+	Cues []Cue
+
+	Styles     []Style   // ASS styles; DefaultStyle is written when there are none
+	ScriptInfo []Field   // ASS [Script Info] lines, in order
+	Sections   []Section // other ASS sections
+
+	VTTHeader string   // text after "WEBVTT" on the first line
+	VTTBlocks []string // WebVTT STYLE and REGION blocks
+
+	Warnings []string // problems the reader worked around (skipped cues, fixed timings)
+}
+
+func (t *Track) warnf(format string, args ...any) {
+	t.Warnings = append(t.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Style returns the named style, or DefaultStyle
+func (t *Track) Style(name string) Style {
+	for _, s := range t.Styles {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+	return DefaultStyle
+}
+
+// ReadFile reads a subtitle file in the format of its extension, or the format its
+// content shows when the extension is unknown
+func ReadFile(file string) (*Track, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	format, _ := FormatOf(file)
+	track, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return track, nil
+}
+
+// Read parses subtitles from r; see Parse
+func Read(r io.Reader, format Format) (*Track, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, format)
+}
+
+// Parse parses subtitles in the given format, or detects it when format is empty.
+// UTF-8 and UTF-16 byte order marks and CRLF or CR line ends are accepted.
+func Parse(data []byte, format Format) (*Track, error) {
+	text := normalizeNewlines(decodeText(data))
+	if format == "" {
+		format = sniff(text)
+	}
+
+	var track *Track
+	var err error
+	switch format {
+	case SRT:
+		track, err = parseSRT(text)
+	case WebVTT:
+		track, err = parseVTT(text)
+	case ASS, SSA:
+		track, err = parseASS(text)
+	default:
+		return nil, fmt.Errorf("unknown subtitle format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	track.fixTimings()
+	return track, nil
+}
+
+// WriteFile writes the track in the format of the file's extension
+func WriteFile(file string, t *Track) error {
+	format, err := FormatOf(file)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err := t.Write(&b, format); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return nil
+}
+
+// Write writes the track in the given format with "\n" line ends and no BOM
+func (t *Track) Write(w io.Writer, format Format) error {
+	var s string
+	switch format {
+	case SRT:
+		s = t.formatSRT()
+	case WebVTT:
+		s = t.formatVTT()
+	case ASS, SSA:
+		s = t.formatASS()
+	default:
+		return fmt.Errorf("unknown subtitle format %q", format)
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// decodeText strips a byte order mark, decoding UTF-16 files to UTF-8
+func decodeText(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 })
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], func(b []byte) uint16 { return uint16(b[1]) | uint16(b[0])<<8 })
+	}
+	return string(data)
+}
+
+func decodeUTF16(data []byte, unit func([]byte) uint16) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = unit(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+func normalizeNewlines(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// sniff guesses the format of a file without a known extension
+func sniff(text string) Format {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "WEBVTT"):
+		return WebVTT
+	case strings.HasPrefix(strings.ToLower(trimmed), "[script info]"):
+		return ASS
+	}
+	return SRT
+}
+
+// timestampRe accepts [H:]MM:SS[.,]fraction with any number of digits and stray spaces
+var timestampRe = regexp.MustCompile(`^(?:(\d+)\s*:\s*)?(\d+)\s*:\s*(\d+)(?:\s*[,.]\s*(\d+))?$`)
+
+// parseTimestamp reads SRT (00:01:02,500), WebVTT (01:02.500) and ASS (0:01:02.50)
+// timestamps, and their common mistakes: '.' for ',' and missing digits or hours
+func parseTimestamp(s string) (time.Duration, error) {
+	m := timestampRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	seconds, _ := strconv.Atoi(m[3])
+	if seconds >= 60 || m[1] != "" && minutes >= 60 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	// The fraction is decimal: "5" is 500 ms, "50" (ASS centiseconds) too
+	fraction := (m[4] + "000")[:3]
+	ms, _ := strconv.Atoi(fraction)
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
+
+// timingEndRe splits the part after the arrow into the end time and the WebVTT settings
+// after white space, so "00:00:xx" is an error rather than "00:00" with settings
+var timingEndRe = regexp.MustCompile(`^\s*((?:\d+\s*:\s*)?\d+\s*:\s*\d+(?:\s*[,.]\s*\d+)?)((?:\s.*)?)$`)
+
+// parseTiming reads a "start --> end [settings]" line; ok is false if the line has
+// no arrow, err is set if it has one but the times are broken
+func parseTiming(line string) (start, end time.Duration, settings string, ok bool, err error) {
+	arrow := strings.Index(line, "->")
+	if arrow < 0 {
+		return 0, 0, "", false, nil
+	}
+	left := strings.TrimRight(line[:arrow], "- \t")
+	m := timingEndRe.FindStringSubmatch(line[arrow+2:])
+	if m == nil {
+		return 0, 0, "", true, fmt.Errorf("invalid timing line %q", line)
+	}
+	if start, err = parseTimestamp(left); err != nil {
+		return 0, 0, "", true, err
+	}
+	if end, err = parseTimestamp(m[1]); err != nil {
+		return 0, 0, "", true, err
+	}
+	return start, end, strings.TrimSpace(m[2]), true, nil
+}
+
+// maxFixedDuration is how long a cue with a broken end time stays on screen at most
+const maxFixedDuration = 2 * time.Second
+
+// fixTimings gives cues that end before they start a short duration, up to the next cue
+func (t *Track) fixTimings() {
+	for i := range t.Cues {
+		c := &t.Cues[i]
+		if c.End >= c.Start {
+			continue
+		}
+		end := c.Start + maxFixedDuration
+		if i+1 < len(t.Cues) && t.Cues[i+1].Start > c.Start {
+			end = min(end, t.Cues[i+1].Start)
+		}
+		t.warnf("cue %d ends before it starts (%s --> %s); end set to %s", i+1, formatSRTTime(c.Start), formatSRTTime(c.End), formatSRTTime(end))
+		c.End = end
+	}
+}
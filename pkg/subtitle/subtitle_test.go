@@ -0,0 +1,147 @@
+package subtitle
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"00:01:02,500", 62500 * time.Millisecond, true},
+		{"00:01:02.500", 62500 * time.Millisecond, true},
+		{"01:02.500", 62500 * time.Millisecond, true},
+		{"0:01:02.50", 62500 * time.Millisecond, true},
+		{"1:02:03,5", time.Hour + 2*time.Minute + 3500*time.Millisecond, true},
+		{" 00 : 00 : 01 , 000 ", time.Second, true},
+		{"00:00:07", 7 * time.Second, true},
+		{"00:60:00,000", 0, false},
+		{"00:00:60,000", 0, false},
+		{"00:00:01;000", 0, false},
+		{"1.5", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTimestamp(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseTimestamp(%q) = %v, %v; want %v, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseSRTMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		texts    []string
+		warnings int
+	}{
+		{
+			name:  "no blank lines between cues",
+			in:    "1\n00:00:01,000 --> 00:00:02,000\nOne\n2\n00:00:03,000 --> 00:00:04,000\nTwo\n",
+			texts: []string{"One", "Two"},
+		},
+		{
+			name:     "broken timing line",
+			in:       "1\n00:00:01,000 --> 00:00:xx,000\nOne\n\n2\n00:00:03,000 --> 00:00:04,000\nTwo\n",
+			texts:    []string{"Two"},
+			warnings: 1,
+		},
+		{
+			name:     "text outside a cue",
+			in:       "stray\n\n1\n00:00:01,000 --> 00:00:02,000\nOne\n",
+			texts:    []string{"One"},
+			warnings: 1,
+		},
+		{
+			name:  "CRLF, BOM and dots for commas",
+			in:    "\ufeff1\r\n00:00:01.000 --> 00:00:02.000\r\nOne\r\n",
+			texts: []string{"One"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track, err := Parse([]byte(tt.in), SRT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var texts []string
+			for _, c := range track.Cues {
+				texts = append(texts, c.Text)
+			}
+			if strings.Join(texts, "|") != strings.Join(tt.texts, "|") {
+				t.Errorf("texts = %q, want %q", texts, tt.texts)
+			}
+			if len(track.Warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", track.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestParseFixesReversedTimes(t *testing.T) {
+	track, err := Parse([]byte("1\n00:00:05,000 --> 00:00:01,000\nOne\n"), SRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := track.Cues[0]; c.End <= c.Start {
+		t.Errorf("cue ends at %v, before its start %v", c.End, c.Start)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	texts := []string{
+		"Hello",
+		"Two\nlines",
+		"<i>italic</i> and <b>bold</b>",
+		Escape("func() { return x }"),
+		Escape("Vec<T> & a < b and c > d"),
+		"မင်္ဂလာပါ",
+	}
+	for _, format := range []Format{SRT, WebVTT, ASS} {
+		for _, text := range texts {
+			track := &Track{Cues: []Cue{{Start: 1500 * time.Millisecond, End: 3 * time.Second, Text: text}}}
+			var b bytes.Buffer
+			if err := track.Write(&b, format); err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := Parse(b.Bytes(), format)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if len(parsed.Cues) != 1 {
+				t.Fatalf("%s %q: %d cues, want 1:\n%s", format, text, len(parsed.Cues), b.String())
+			}
+			c := parsed.Cues[0]
+			if c.Text != text || c.Start != track.Cues[0].Start || c.End != track.Cues[0].End {
+				t.Errorf("%s: %q %v-%v came back as %q %v-%v", format, text, track.Cues[0].Start, track.Cues[0].End, c.Text, c.Start, c.End)
+			}
+		}
+	}
+}
+
+func TestSRTText(t *testing.T) {
+	tests := []struct {
+		in, markup, plain string
+	}{
+		{"<i>Hi</i>", "<i>Hi</i>", "Hi"},
+		{"{ return x }", "&#123; return x &#125;", "{ return x }"},
+		{"Vec<T>", "Vec&lt;T&gt;", "Vec<T>"},
+		{"a < b and c > d", "a &lt; b and c &gt; d", "a < b and c > d"},
+		{"{\\an8}Top", "{\\an8}Top", "Top"},
+		{"Tom & Jerry", "Tom &amp; Jerry", "Tom & Jerry"},
+	}
+	for _, tt := range tests {
+		if got := srtToMarkup(tt.in); got != tt.markup {
+			t.Errorf("srtToMarkup(%q) = %q, want %q", tt.in, got, tt.markup)
+		}
+		if got := PlainText(srtToMarkup(tt.in)); got != tt.plain {
+			t.Errorf("PlainText(%q) = %q, want %q", tt.in, got, tt.plain)
+		}
+	}
+}
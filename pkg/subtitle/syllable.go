@@ -0,0 +1,140 @@
+package subtitle
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Burmese writes no spaces between words, so lines may only break between syllables.
+// The rules follow Sylbreak (Ye Kyaw Thu): a syllable starts at a consonant that is
+// neither stacked under the previous one (after ္) nor killed by a following ် or ္,
+// and at independent vowels, digits, symbols and other scripts.
+
+func isMyanmar(r rune) bool {
+	return r >= 0x1000 && r <= 0x109F || r >= 0xAA60 && r <= 0xAA7F || r >= 0xA9E0 && r <= 0xA9FF
+}
+
+func isMyanmarConsonant(r rune) bool {
+	return r >= 0x1000 && r <= 0x1021
+}
+
+const (
+	myanmarVirama = 0x1039 // ္ stacks the next consonant
+	myanmarAsat   = 0x103A // ် kills the consonant's vowel
+)
+
+// Syllables splits Burmese text into syllables; runs of other scripts, digits and
+// spaces stay together ("ကျွန်တော် YouTube" gives ကျွန် တော် " " YouTube)
+func Syllables(text string) []string {
+	runes := []rune(text)
+	var syllables []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if syllableStart(runes, i) {
+			syllables = append(syllables, string(runes[start:i]))
+			start = i
+		}
+	}
+	if len(runes) > 0 {
+		syllables = append(syllables, string(runes[start:]))
+	}
+	return syllables
+}
+
+// syllableStart reports whether a syllable (or a run of another script) starts at runes[i]
+func syllableStart(runes []rune, i int) bool {
+	r, prev := runes[i], runes[i-1]
+	var next rune
+	if i+1 < len(runes) {
+		next = runes[i+1]
+	}
+
+	switch {
+	case isMyanmarConsonant(r):
+		return prev != myanmarVirama && next != myanmarAsat && next != myanmarVirama
+	case isMyanmar(r):
+		if unicode.IsMark(r) {
+			return false
+		}
+		if unicode.IsDigit(r) {
+			return !unicode.IsDigit(prev)
+		}
+		return true // independent vowels and symbols such as ၏ ။
+	case unicode.IsMark(r):
+		return false
+	}
+	// Other scripts: a new run where the script, or spacing, changes
+	return isMyanmar(prev) || unicode.IsSpace(r) != unicode.IsSpace(prev)
+}
+
+// Characters counts what a reader sees: runes without combining marks, so a Burmese
+// consonant with its vowel signs and medials counts once
+func Characters(text string) int {
+	n := 0
+	for _, r := range text {
+		if !unicode.IsMark(r) && r != '\u200b' {
+			n++
+		}
+	}
+	return n
+}
+
+// noBreakBefore are punctuation marks that must not start a line
+const noBreakBefore = "။၊.,!?;:)]}%»”’、。，！？；：）」』"
+
+// noBreakAfter are punctuation marks that must not end a line
+const noBreakAfter = "([{«“‘（「『"
+
+// sentenceEnds and clauseEnds are preferred places to break lines and split cues
+const (
+	sentenceEnds = "။.!?。！？"
+	clauseEnds   = "၊,;:、，；："
+)
+
+// breakUnits splits text into pieces a line may break between: words (with their
+// trailing space) for spaced scripts, syllables for Burmese, characters for CJK
+func breakUnits(text string) []string {
+	runes := []rune(text)
+	var units []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		r, prev := runes[i], runes[i-1]
+		var brk bool
+		switch {
+		case unicode.IsSpace(r):
+			brk = false // spaces stay with the word before them
+		case unicode.IsSpace(prev):
+			brk = true
+		case isCJK(r) || isCJK(prev):
+			brk = !unicode.IsMark(r)
+		case isMyanmar(r) || isMyanmar(prev):
+			brk = syllableStart(runes, i)
+		}
+		if brk && (strings.ContainsRune(noBreakBefore, r) || strings.ContainsRune(noBreakAfter, prev)) {
+			brk = false
+		}
+		if brk {
+			units = append(units, string(runes[start:i]))
+			start = i
+		}
+	}
+	if len(runes) > 0 {
+		units = append(units, string(runes[start:]))
+	}
+	return units
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// endsWith reports whether a unit ends with one of marks, ignoring trailing spaces
+func endsWith(unit, marks string) bool {
+	unit = strings.TrimRightFunc(unit, unicode.IsSpace)
+	for _, m := range marks {
+		if strings.HasSuffix(unit, string(m)) {
+			return true
+		}
+	}
+	return false
+}
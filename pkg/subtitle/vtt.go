@@ -0,0 +1,87 @@
+package subtitle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// formatVTTTime formats a duration as HH:MM:SS.mmm
+func formatVTTTime(d time.Duration) string {
+	return strings.Replace(formatSRTTime(d), ",", ".", 1)
+}
+
+// vttToMarkup resolves the entities cue markup has no use for; WebVTT braces are text
+var vttToMarkup = strings.NewReplacer("&nbsp;", "\u00a0", "&lrm;", "\u200e", "&rlm;", "\u200f", "{", "&#123;", "}", "&#125;")
+
+func parseVTT(text string) (*Track, error) {
+	t := &Track{}
+	lines := strings.Split(text, "\n")
+
+	i := 0
+	if header, ok := strings.CutPrefix(strings.TrimSpace(lines[0]), "WEBVTT"); ok {
+		t.VTTHeader = strings.TrimSpace(header)
+		// The rest of the header block (old Kind:/Language: metadata) is dropped
+		for i = 1; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		}
+	} else {
+		t.warnf("line 1: WEBVTT header missing")
+	}
+
+	// Blocks are separated by blank lines: comments, styles and regions, then cues
+	for i < len(lines) {
+		start := i
+		for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+			i++
+		}
+		block := lines[start:i]
+		for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+			i++
+		}
+		if len(block) == 0 {
+			continue
+		}
+
+		first := strings.TrimSpace(block[0])
+		switch {
+		case first == "NOTE" || strings.HasPrefix(first, "NOTE ") || strings.HasPrefix(first, "NOTE\t"):
+			continue
+		case first == "STYLE" || first == "REGION":
+			t.VTTBlocks = append(t.VTTBlocks, strings.Join(block, "\n"))
+			continue
+		}
+		for _, c := range parseCueBlocks(block, start, t) {
+			c.Text = vttToMarkup.Replace(c.Text)
+			t.Cues = append(t.Cues, c)
+		}
+	}
+	return t, nil
+}
+
+// formatVTT writes cue identifiers and settings, and the WebVTT subset of the markup
+func (t *Track) formatVTT() string {
+	var b strings.Builder
+	b.WriteString("WEBVTT")
+	if t.VTTHeader != "" {
+		b.WriteString(" " + t.VTTHeader)
+	}
+	b.WriteString("\n\n")
+	for _, block := range t.VTTBlocks {
+		b.WriteString(block + "\n\n")
+	}
+
+	for _, c := range t.Cues {
+		if c.Comment {
+			continue
+		}
+		if c.ID != "" && !strings.Contains(c.ID, "-->") {
+			b.WriteString(c.ID + "\n")
+		}
+		fmt.Fprintf(&b, "%s --> %s", formatVTTTime(c.Start), formatVTTTime(c.End))
+		if c.Settings != "" {
+			b.WriteString(" " + c.Settings)
+		}
+		fmt.Fprintf(&b, "\n%s\n\n", markupToVTT(c.Text))
+	}
+	return b.String()
+}